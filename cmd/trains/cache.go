@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"trains/internal/cache"
+	"trains/internal/logging"
+	"trains/internal/output"
+	"trains/internal/parser"
+)
+
+// runCacheList handles the cache list command
+func runCacheList(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	entries, err := cache.List(cache.ActiveStore())
+	if err != nil {
+		return fmt.Errorf("error listing cache: %v", err)
+	}
+
+	if format != output.FormatText {
+		return output.WriteCacheEntries(os.Stdout, format, entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("Cache %s is empty\n", cache.Dir())
+		return nil
+	}
+
+	fmt.Printf("%-8s %10s %9s  %s\n", "STATUS", "AGE", "SIZE", "URL")
+	for _, entry := range entries {
+		url := entry.URL
+		if entry.Status == cache.StatusCorrupt {
+			url = fmt.Sprintf("%s (%v)", entry.Key, entry.Err)
+		}
+		fmt.Printf("%-8s %10s %9s  %s\n", entry.Status, formatAge(entry), formatBytes(entry.Size), url)
+	}
+	return nil
+}
+
+// runCacheStats handles the cache stats command
+func runCacheStats(cmd *cobra.Command, args []string) error {
+	entries, err := cache.List(cache.ActiveStore())
+	if err != nil {
+		return fmt.Errorf("error listing cache: %v", err)
+	}
+	stats := cache.Summarize(entries)
+
+	parsed, err := cache.List(cache.ParsedStore())
+	if err != nil {
+		return fmt.Errorf("error listing parse results: %v", err)
+	}
+
+	fmt.Printf("Directory: %s\n", cache.Dir())
+	fmt.Printf("Backend:   %s\n", cache.CurrentSettings().Backend)
+	fmt.Printf("Entries:   %d (%d fresh, %d expired, %d corrupt)\n", stats.Entries, stats.Fresh, stats.Expired, stats.Corrupt)
+	fmt.Printf("Size:      %s\n", formatBytes(stats.TotalBytes))
+	fmt.Printf("Parsed:    %d parse results (%s)\n", len(parsed), formatBytes(cache.Summarize(parsed).TotalBytes))
+	if !stats.Oldest.IsZero() {
+		fmt.Printf("Oldest:    %s\n", stats.Oldest.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Newest:    %s\n", stats.Newest.Format("2006-01-02 15:04:05"))
+	}
+	return nil
+}
+
+// runCacheShow handles the cache show command
+func runCacheShow(cmd *cobra.Command, args []string) error {
+	url := args[0]
+
+	showContent, err := cmd.Flags().GetBool("content")
+	if err != nil {
+		return fmt.Errorf("error getting content flag: %v", err)
+	}
+
+	entry, err := cache.Find(cache.ActiveStore(), url)
+	if err != nil {
+		return err
+	}
+
+	if showContent {
+		stored, err := cache.ActiveStore().Load(url)
+		if err != nil {
+			return err
+		}
+		fmt.Print(stored.Content)
+		return nil
+	}
+
+	fmt.Printf("URL:       %s\n", url)
+	fmt.Printf("Key:       %s\n", entry.Key)
+	fmt.Printf("Status:    %s\n", entry.Status)
+	if entry.Err != nil {
+		fmt.Printf("Problem:   %v\n", entry.Err)
+	}
+	if !entry.Timestamp.IsZero() {
+		fmt.Printf("Cached:    %s (%s ago)\n", entry.Timestamp.Format("2006-01-02 15:04:05"), formatAge(entry))
+	}
+	fmt.Printf("Size:      %s\n", formatBytes(entry.Size))
+	return nil
+}
+
+// runCachePurge handles the cache purge command
+func runCachePurge(cmd *cobra.Command, args []string) error {
+	olderThan, err := cmd.Flags().GetString("older-than")
+	if err != nil {
+		return fmt.Errorf("error getting older-than flag: %v", err)
+	}
+	urlPattern, err := cmd.Flags().GetString("url-pattern")
+	if err != nil {
+		return fmt.Errorf("error getting url-pattern flag: %v", err)
+	}
+	all, err := cmd.Flags().GetBool("all")
+	if err != nil {
+		return fmt.Errorf("error getting all flag: %v", err)
+	}
+
+	var options cache.PurgeOptions
+	if olderThan != "" {
+		if options.OlderThan, err = cache.ParseAge(olderThan); err != nil {
+			return err
+		}
+	}
+	if options.URLPattern, err = cache.ParseURLPattern(urlPattern); err != nil {
+		return err
+	}
+
+	// Refuse to wipe a shared cache by accident
+	if options.OlderThan == 0 && options.URLPattern == nil && !all {
+		return fmt.Errorf("refusing to purge every entry: use --older-than, --url-pattern or --all")
+	}
+
+	removed, err := cache.Purge(cache.ActiveStore(), options)
+	for _, entry := range removed {
+		logging.Infof("🗑️  Removed %s", describeCacheEntry(entry))
+	}
+	if err != nil {
+		return fmt.Errorf("error purging cache: %v", err)
+	}
+
+	// Parse results are derived from pages, so they are pruned by the same filters
+	removedParsed, err := cache.Purge(cache.ParsedStore(), options)
+	if err != nil {
+		return fmt.Errorf("error purging parse results: %v", err)
+	}
+
+	fmt.Printf("Purged %d cache entries and %d parse results from %s\n", len(removed), len(removedParsed), cache.Dir())
+	return nil
+}
+
+// runCacheVerify handles the cache verify command
+func runCacheVerify(cmd *cobra.Command, args []string) error {
+	remove, err := cmd.Flags().GetBool("remove")
+	if err != nil {
+		return fmt.Errorf("error getting remove flag: %v", err)
+	}
+
+	corruptCount := 0
+	for _, store := range []cache.Store{cache.ActiveStore(), cache.ParsedStore()} {
+		corrupt, err := cache.Verify(store)
+		if err != nil {
+			return fmt.Errorf("error verifying cache: %v", err)
+		}
+		corruptCount += len(corrupt)
+
+		for _, entry := range corrupt {
+			fmt.Printf("❌ %s: %v\n", entry.Key, entry.Err)
+			if remove {
+				if err := store.Remove(entry.Key); err != nil {
+					return fmt.Errorf("error removing %s: %v", entry.Key, err)
+				}
+			}
+		}
+	}
+
+	if corruptCount == 0 {
+		fmt.Printf("✅ All cache entries in %s are valid\n", cache.Dir())
+		return nil
+	}
+
+	if remove {
+		fmt.Printf("Removed %d corrupt cache entries\n", corruptCount)
+		return nil
+	}
+	return fmt.Errorf("%d corrupt cache entries found (run with --remove to delete them)", corruptCount)
+}
+
+// describeCacheEntry names an entry by URL, or by storage key when the URL is unknown
+func describeCacheEntry(entry cache.EntryInfo) string {
+	if entry.URL == "" {
+		return entry.Key
+	}
+	return entry.URL
+}
+
+// formatAge formats how long ago an entry was cached, e.g. "2h 5m" or "3d 4h"
+func formatAge(entry cache.EntryInfo) string {
+	if entry.Timestamp.IsZero() {
+		return "-"
+	}
+	minutes := int(entry.Age() / time.Minute)
+	if days := minutes / parser.MinutesPerDay; days > 0 {
+		return fmt.Sprintf("%dd %dh", days, minutes%parser.MinutesPerDay/parser.MinutesPerHour)
+	}
+	return parser.FormatMinutes(minutes)
+}
+
+// formatBytes formats a size in bytes as B, KB or MB
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
@@ -1,34 +1,75 @@
 package main
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"trains/internal/cache"
+	"trains/internal/client"
+	"trains/internal/fixtures"
+	"trains/internal/logging"
+	"trains/internal/parser"
+)
 
 var (
 	// Global flags
-	cacheEnabled bool
-	
+	cacheEnabled  bool
+	outputFormat  string
+	recordDir     string
+	replayDir     string
+	cacheSettings = cache.DefaultSettings()
+	cacheBackend  string
+	staleIfError  bool
+	offline       bool
+
+	// Logging flags
+	verbose   bool
+	quiet     bool
+	logFormat string
+
+	// Network flags
+	requestTimeout time.Duration
+	retryPolicy    = client.DefaultRetryPolicy()
+	requestsPerSec float64
+	concurrency    int
+
+	// newFetcher builds the fetcher used by commands; tests replace it to run offline
+	newFetcher = defaultFetcher
+
 	// Root command
 	rootCmd = &cobra.Command{
 		Use:   "trains",
 		Short: "Railway route analysis tool",
 		Long: `Trains CLI - A powerful command-line tool for analyzing Indian Railways 
 train routes, connections, and timetables with intelligent caching and connection optimization.`,
+		PersistentPreRunE: configureRun,
 	}
-	
+
 	// Via search command
 	viaSearchCmd = &cobra.Command{
 		Use:   "viasearch",
 		Short: "Analyze train routes via intermediate stations",
 		Long: `Analyze train connections via intermediate stations and find optimal routes.
 
-This command fetches train data from etrain.info URLs and analyzes possible connections
-with realistic layover times (1-4 hours) and matching running days.`,
-		Example: `  trains viasearch -url="https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN"
+This command fetches train data from etrain.info and analyzes possible connections
+with realistic layover times (1-4 hours) and matching running days. The route is given
+either by station codes (--from, --to, --via) or by a full etrain.info URL (--url).
+Several transit stations can be analyzed in one run; their connections are merged into
+one list ranked by total journey time.`,
+		Example: `  trains viasearch --from BL --to NED --via KYN
+  trains viasearch --from BL --to NED --via KYN,PUNE,MMR
+  trains viasearch --from BL --to NED --via auto --via-top 8
+  trains viasearch --from BL --to NED --via KYN --day=wed
+  trains viasearch -url="https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN"
   trains viasearch -url="https://etrain.info/trains/..." --no-cache
   trains viasearch -url="https://etrain.info/trains/..." -d=wed
   trains viasearch -url="https://etrain.info/trains/..." --day=sunday`,
 		RunE: runViaSearch,
 	}
-	
+
 	// Top search command
 	topSearchCmd = &cobra.Command{
 		Use:   "topsearch",
@@ -43,28 +84,369 @@ routes with their transit stations, train counts, and distances.`,
   trains topsearch --url="https://etrain.info/transit/BL-NED?page=1" --max-distance=900`,
 		RunE: runTopSearch,
 	}
+
+	// Plan command
+	planCmd = &cobra.Command{
+		Use:   "plan",
+		Short: "Find the best train pairs between two stations",
+		Long: `Plan a journey between two stations in one step.
+
+This command discovers transit routes like topsearch, follows each route's details link
+through the viasearch analysis and returns the best actual train pairs overall, ranked by
+total journey time.`,
+		Example: `  trains plan --from BL --to NED
+  trains plan --from BL --to NED --routes 15 --limit 5
+  trains plan --from BL --to NED --day=fri -o json`,
+		RunE: runPlan,
+	}
+
+	// Doctor command
+	doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that etrain.info pages still have the format the parsers expect",
+		Long: `Check that etrain.info pages still have the format the parsers expect.
+
+This command fetches a transit listing and a via-search page and looks for structural drift:
+missing tables or markers, changed cell counts and unknown keys in the train data. Searches run
+the same checks and fail with an "upstream format changed" error instead of reporting no results,
+so this tells "no trains" apart from "broken parser". Pages come from the cache while fresh;
+add --no-cache to check the live site.`,
+		Example: `  trains doctor --no-cache
+  trains doctor --from BL --to NED --via KYN
+  trains doctor --url "https://etrain.info/transit/BL-NED?page=1"`,
+		Args: cobra.NoArgs,
+		RunE: runDoctor,
+	}
+
+	// Stations command
+	stationsCmd = &cobra.Command{
+		Use:   "stations",
+		Short: "Look up station codes by name",
+		Long: `Look up station codes in the local station directory.
+
+Station names and codes are collected from every transit page that topsearch, plan and
+viasearch --via auto parse. Commands that take a station accept a name from the directory
+as well as a code; ambiguous names are listed so that one can be chosen.`,
+		Example: `  trains stations search nanded
+  trains stations search "kalyan jn"
+  trains stations list -o csv`,
+	}
+
+	stationsSearchCmd = &cobra.Command{
+		Use:   "search <name>",
+		Short: "Find stations by name or code, tolerating typos",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runStationsSearch,
+	}
+
+	stationsListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List every known station",
+		Args:  cobra.NoArgs,
+		RunE:  runStationsList,
+	}
+
+	// Cache command
+	cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and prune the response cache",
+		Long: `Inspect and prune the response cache without deleting everything.
+
+Each cached page is reported with its URL, age, size and status: fresh entries are served
+as cache hits, expired entries are refetched on next use and corrupt entries are ignored.`,
+		Example: `  trains cache list
+  trains cache stats
+  trains cache show "https://etrain.info/transit/BL-NED"
+  trains cache purge --older-than 7d
+  trains cache purge --url-pattern "transit/BL-"
+  trains cache verify --remove`,
+	}
+
+	cacheListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List cached pages with age, size and status",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheList,
+	}
+
+	cacheStatsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Summarize the cache",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheStats,
+	}
+
+	cacheShowCmd = &cobra.Command{
+		Use:   "show <url>",
+		Short: "Show the cache entry for a URL",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runCacheShow,
+	}
+
+	cachePurgeCmd = &cobra.Command{
+		Use:   "purge",
+		Short: "Remove cache entries by age or URL",
+		Long: `Remove cache entries by age or URL.
+
+Entries must match every given filter. --url-pattern is a regular expression, so plain
+text matches any URL containing it. Removing every entry requires --all.`,
+		Args: cobra.NoArgs,
+		RunE: runCachePurge,
+	}
+
+	cacheVerifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Check every cache entry for corruption",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheVerify,
+	}
 )
 
 // initCommands initializes all CLI commands and flags
 func initCommands() {
-	// Add persistent flags to root command  
+	// Add persistent flags to root command
 	rootCmd.PersistentFlags().BoolVar(&cacheEnabled, "cache", true, "Enable/disable caching")
 	rootCmd.PersistentFlags().Bool("no-cache", false, "Disable caching (same as --cache=false)")
-	
+	rootCmd.PersistentFlags().StringVar(&cacheSettings.Dir, "cache-dir", cacheSettings.Dir, "Cache directory")
+	rootCmd.PersistentFlags().StringVar(&cacheBackend, "cache-backend", string(cache.BackendFile), "Cache storage: file (one compressed file per page) or bolt (single database file)")
+	rootCmd.PersistentFlags().Var((*ageValue)(&cacheSettings.TTL), "cache-ttl", "Cache expiry, also used for transit and train pages unless set separately (e.g. 12h, 3d)")
+	rootCmd.PersistentFlags().Var((*ageValue)(&cacheSettings.TransitTTL), "transit-ttl", "Cache expiry of transit listing pages")
+	rootCmd.PersistentFlags().Var((*ageValue)(&cacheSettings.TrainTTL), "train-ttl", "Cache expiry of train listing pages")
+	rootCmd.PersistentFlags().BoolVar(&staleIfError, "stale-if-error", true, "Serve expired cache entries with a warning when the network fetch fails")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "Never touch the network, serve cached pages even if expired")
+	rootCmd.PersistentFlags().StringVar(&recordDir, "record", "", "Record every fetched page into a fixture bundle in this directory")
+	rootCmd.PersistentFlags().StringVar(&replayDir, "replay", "", "Serve all pages from the fixture bundle in this directory (no network)")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.MarkFlagsMutuallyExclusive("offline", "replay")
+	rootCmd.PersistentFlags().DurationVar(&requestTimeout, "timeout", client.DefaultTimeout, "Per-request network timeout")
+	rootCmd.PersistentFlags().IntVar(&retryPolicy.MaxRetries, "retries", retryPolicy.MaxRetries, "Retries for transient network failures (0 = no retries)")
+	rootCmd.PersistentFlags().DurationVar(&retryPolicy.BaseDelay, "retry-delay", retryPolicy.BaseDelay, "Delay before the first retry, doubled for each further retry")
+	rootCmd.PersistentFlags().DurationVar(&retryPolicy.MaxDelay, "retry-max-delay", retryPolicy.MaxDelay, "Maximum delay between retries")
+	rootCmd.PersistentFlags().Float64Var(&retryPolicy.Jitter, "retry-jitter", retryPolicy.Jitter, "Fraction of each retry delay that is randomized (0-1)")
+	rootCmd.PersistentFlags().BoolVar(&retryPolicy.RespectRetryAfter, "retry-after", retryPolicy.RespectRetryAfter, "Honor Retry-After headers on 429/503 responses")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", 4, "Number of pages fetched in parallel")
+	rootCmd.PersistentFlags().Float64Var(&requestsPerSec, "rps", 2, "Maximum network requests per second shared by all workers (0 = unlimited)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json, csv, ndjson)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Also log debug messages such as cache hits and parse statistics")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Only log warnings and errors")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", string(logging.FormatText), "Log format on stderr (text, json)")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+
 	// Add viasearch command
 	rootCmd.AddCommand(viaSearchCmd)
-	
+
 	// Add topsearch command
 	rootCmd.AddCommand(topSearchCmd)
-	
+
+	// Add plan command
+	rootCmd.AddCommand(planCmd)
+
+	// Add doctor command
+	rootCmd.AddCommand(doctorCmd)
+
+	// Add stations command and its subcommands
+	rootCmd.AddCommand(stationsCmd)
+	stationsCmd.AddCommand(stationsSearchCmd, stationsListCmd)
+
+	// Add cache command and its subcommands
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cacheStatsCmd, cacheShowCmd, cachePurgeCmd, cacheVerifyCmd)
+
 	// Add flags specific to viasearch command
-	viaSearchCmd.Flags().StringP("url", "u", "", "URL to fetch train data from (required)")
-	viaSearchCmd.Flags().StringP("day", "d", "", "Filter by day of week (sun, mon, tue, wed, thu, fri, sat)")
-	viaSearchCmd.MarkFlagRequired("url")
-	
+	viaSearchCmd.Flags().StringP("url", "u", "", "URL to fetch train data from")
+	viaSearchCmd.Flags().String("from", "", "Source station code or name (e.g. BL)")
+	viaSearchCmd.Flags().String("to", "", "Destination station code or name (e.g. NED)")
+	viaSearchCmd.Flags().String("via", "", "Transit station codes or names, comma-separated (e.g. KYN,PUNE), or \"auto\" for the top transit stations")
+	viaSearchCmd.Flags().Int("via-top", 5, "Number of top transit stations used by --via auto")
+	viaSearchCmd.Flags().StringP("day", "d", "", "Filter by departure day from the source station (sun, mon, tue, wed, thu, fri, sat)")
+	viaSearchCmd.Flags().String("date", "", "Travel date from the source station (YYYY-MM-DD, today or tomorrow); filters by its weekday and shows dated times")
+	addConstraintFlags(viaSearchCmd)
+	viaSearchCmd.Flags().String("sort", string(sortByTotal), "Sort by total, layover, departure, arrival or score")
+	viaSearchCmd.Flags().IntP("limit", "l", 0, "Limit number of connections to show (0 = no limit)")
+	viaSearchCmd.Flags().Bool("pareto", false, "Only show connections no other connection beats on departure, arrival, total time and layover")
+	viaSearchCmd.MarkFlagsRequiredTogether("from", "to", "via")
+	viaSearchCmd.MarkFlagsMutuallyExclusive("url", "from")
+	viaSearchCmd.MarkFlagsMutuallyExclusive("url", "to")
+	viaSearchCmd.MarkFlagsMutuallyExclusive("url", "via")
+	viaSearchCmd.MarkFlagsOneRequired("url", "from")
+	viaSearchCmd.MarkFlagsMutuallyExclusive("day", "date")
+
 	// Add flags specific to topsearch command
 	topSearchCmd.Flags().StringP("url", "u", "", "URL to fetch transit route data from (required)")
 	topSearchCmd.Flags().IntP("limit", "l", 10, "Limit number of routes to show (default: 10)")
 	topSearchCmd.Flags().IntP("max-distance", "m", 0, "Maximum distance in kilometers (0 = no limit)")
 	topSearchCmd.MarkFlagRequired("url")
-}
\ No newline at end of file
+
+	// Add flags specific to plan command
+	planCmd.Flags().String("from", "", "Source station code or name (e.g. BL) (required)")
+	planCmd.Flags().String("to", "", "Destination station code or name (e.g. NED) (required)")
+	planCmd.Flags().Int("routes", 10, "Number of transit routes to follow (shortest distance first)")
+	planCmd.Flags().IntP("limit", "l", 10, "Limit number of train pairs to show (0 = no limit)")
+	planCmd.Flags().String("sort", string(sortByTotal), "Sort by total, layover, departure, arrival or score")
+	planCmd.Flags().Bool("pareto", false, "Only show connections no other connection beats on departure, arrival, total time and layover")
+	planCmd.Flags().StringP("day", "d", "", "Filter by departure day from the source station (sun, mon, tue, wed, thu, fri, sat)")
+	planCmd.Flags().String("date", "", "Travel date from the source station (YYYY-MM-DD, today or tomorrow); filters by its weekday and shows dated times")
+	addConstraintFlags(planCmd)
+	planCmd.MarkFlagRequired("from")
+	planCmd.MarkFlagRequired("to")
+	planCmd.MarkFlagsMutuallyExclusive("day", "date")
+
+	// Add flags specific to doctor command
+	doctorCmd.Flags().String("from", "BL", "Source station code of the sample pages")
+	doctorCmd.Flags().String("to", "NED", "Destination station code of the sample pages")
+	doctorCmd.Flags().String("via", "KYN", "Transit station code of the sample via-search page")
+	doctorCmd.Flags().StringSlice("url", nil, "Check these transit or via-search URLs instead of the sample pages")
+
+	// Add flags specific to stations subcommands
+	stationsSearchCmd.Flags().IntP("limit", "l", 10, "Limit number of stations to show (0 = no limit)")
+
+	// Add flags specific to cache subcommands
+	cacheShowCmd.Flags().Bool("content", false, "Print the cached page instead of its metadata")
+	cachePurgeCmd.Flags().String("older-than", "", "Only remove entries cached longer ago than this (e.g. 36h, 7d)")
+	cachePurgeCmd.Flags().String("url-pattern", "", "Only remove entries whose URL matches this regular expression")
+	cachePurgeCmd.Flags().Bool("all", false, "Remove every entry when no other filter is given")
+	cacheVerifyCmd.Flags().Bool("remove", false, "Delete corrupt entries")
+
+	registerCompletions()
+}
+
+// addConstraintFlags adds the layover and journey time flags to a command
+func addConstraintFlags(cmd *cobra.Command) {
+	defaults := parser.DefaultConstraints()
+	cmd.Flags().Duration("min-layover", time.Duration(defaults.MinLayoverMinutes)*time.Minute, "Minimum layover at the transit station")
+	cmd.Flags().Duration("max-layover", time.Duration(defaults.MaxLayoverMinutes)*time.Minute, "Maximum layover at the transit station")
+	cmd.Flags().Duration("max-journey", time.Duration(defaults.MaxJourneyMinutes)*time.Minute, "Total journey time must be under this")
+}
+
+// configureRun applies the global flags before any command runs
+func configureRun(cmd *cobra.Command, args []string) error {
+	if err := configureLogging(); err != nil {
+		return err
+	}
+
+	// --no-cache is the same as --cache=false for every command
+	if noCache, _ := cmd.Root().PersistentFlags().GetBool("no-cache"); noCache {
+		cacheEnabled = false
+	}
+	return configureCache(cmd, args)
+}
+
+// configureLogging sets up the logger shared by all packages from the logging flags
+func configureLogging() error {
+	format, err := logging.ParseFormat(logFormat)
+	if err != nil {
+		return err
+	}
+	logging.Setup(os.Stderr, logging.Level(verbose, quiet), format)
+	return nil
+}
+
+// configureCache applies the cache flags before any command runs
+func configureCache(cmd *cobra.Command, args []string) error {
+	settings := cacheSettings
+
+	backend, err := cache.ParseBackend(cacheBackend)
+	if err != nil {
+		return err
+	}
+	settings.Backend = backend
+
+	// --cache-ttl applies to every resource whose TTL was not given explicitly
+	flags := cmd.Root().PersistentFlags()
+	if flags.Changed("cache-ttl") {
+		if !flags.Changed("transit-ttl") {
+			settings.TransitTTL = settings.TTL
+		}
+		if !flags.Changed("train-ttl") {
+			settings.TrainTTL = settings.TTL
+		}
+	}
+
+	if err := cache.Configure(settings); err != nil {
+		return fmt.Errorf("error configuring cache: %v", err)
+	}
+	return nil
+}
+
+// ageValue is a duration flag that also accepts days, e.g. "7d"
+type ageValue time.Duration
+
+// String implements pflag.Value
+func (a *ageValue) String() string {
+	age := time.Duration(*a)
+	if age > 0 && age%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", age/(24*time.Hour))
+	}
+	return age.String()
+}
+
+// Set implements pflag.Value
+func (a *ageValue) Set(value string) error {
+	age, err := cache.ParseAge(value)
+	if err != nil {
+		return err
+	}
+	*a = ageValue(age)
+	return nil
+}
+
+// Type implements pflag.Value
+func (a *ageValue) Type() string {
+	return "duration"
+}
+
+// defaultFetcher builds the fetcher chain from the global flags
+func defaultFetcher() (client.Fetcher, error) {
+	// Replay serves recorded pages only, bypassing both network and cache
+	if replayDir != "" {
+		pages, err := fixtures.Load(replayDir)
+		if err != nil {
+			return nil, fmt.Errorf("error loading fixture bundle: %v", err)
+		}
+		return client.ReplayFetcher{Pages: pages}, nil
+	}
+
+	if offline && !cacheEnabled {
+		return nil, fmt.Errorf("--offline needs the cache: it cannot be combined with --no-cache")
+	}
+
+	if requestTimeout <= 0 {
+		return nil, fmt.Errorf("invalid timeout %v: must be positive", requestTimeout)
+	}
+	if err := retryPolicy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if concurrency < 1 {
+		return nil, fmt.Errorf("invalid concurrency %d: must be at least 1", concurrency)
+	}
+	if requestsPerSec < 0 {
+		return nil, fmt.Errorf("invalid rps %g: must not be negative", requestsPerSec)
+	}
+
+	// Every retry attempt waits for the rate limiter, cache hits never do
+	var fetcher client.Fetcher = client.NetworkFetcher{Timeout: requestTimeout}
+	if requestsPerSec > 0 {
+		fetcher = client.RateLimitedFetcher{Next: fetcher, Limiter: client.NewRateLimiter(requestsPerSec, 1)}
+	}
+	fetcher = client.RetryingFetcher{Next: fetcher, Policy: retryPolicy}
+
+	if cacheEnabled {
+		// Initialize cache directory if caching is enabled
+		if err := cache.InitCache(); err != nil {
+			return nil, fmt.Errorf("error initializing cache: %v", err)
+		}
+		fetcher = client.CachingFetcher{Next: fetcher, StaleIfError: staleIfError, Offline: offline}
+	}
+
+	// Record outermost so pages served from the cache end up in the bundle too
+	if recordDir != "" {
+		recorder, err := fixtures.NewRecorder(recordDir)
+		if err != nil {
+			return nil, fmt.Errorf("error opening fixture bundle: %v", err)
+		}
+		fetcher = client.RecordingFetcher{Next: fetcher, Recorder: recorder}
+	}
+
+	return fetcher, nil
+}
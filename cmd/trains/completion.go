@@ -0,0 +1,197 @@
+package main
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"trains/internal/cache"
+	"trains/internal/parser"
+	"trains/internal/stations"
+)
+
+// completionFunc is the signature of cobra's dynamic completion hooks
+type completionFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// registerCompletions adds dynamic completion to the flags and arguments that take days,
+// dates, stations and cached URLs
+func registerCompletions() {
+	for _, cmd := range []*cobra.Command{viaSearchCmd, planCmd} {
+		cmd.RegisterFlagCompletionFunc("day", completeDays)
+		cmd.RegisterFlagCompletionFunc("date", completeDates)
+	}
+	for _, cmd := range []*cobra.Command{viaSearchCmd, planCmd, doctorCmd} {
+		cmd.RegisterFlagCompletionFunc("from", completeStations)
+		cmd.RegisterFlagCompletionFunc("to", completeStations)
+	}
+	viaSearchCmd.RegisterFlagCompletionFunc("via", completeStationList)
+	doctorCmd.RegisterFlagCompletionFunc("via", completeStations)
+
+	viaSearchCmd.RegisterFlagCompletionFunc("url", completeCachedURLs(cache.ResourceTrain))
+	topSearchCmd.RegisterFlagCompletionFunc("url", completeCachedURLs(cache.ResourceTransit))
+	doctorCmd.RegisterFlagCompletionFunc("url", completeCachedURLs(cache.ResourceTransit, cache.ResourceTrain))
+
+	cacheShowCmd.ValidArgsFunction = firstArgOnly(completeCachedURLs())
+	stationsSearchCmd.ValidArgsFunction = completeStationNames
+}
+
+// completeDays offers the accepted --day inputs with the day each stands for
+func completeDays(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := strings.ToLower(toComplete)
+
+	var completions []string
+	for _, input := range parser.DayInputs() {
+		if strings.HasPrefix(input[0], prefix) {
+			completions = append(completions, input[0]+"\t"+input[1])
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDates offers today, tomorrow and the dates of the coming week with their weekdays
+func completeDates(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	today, _ := parser.ParseTravelDate("today", time.Now())
+	candidates := []string{
+		"today\t" + today.Format("Monday 2 Jan"),
+		"tomorrow\t" + today.AddDate(0, 0, 1).Format("Monday 2 Jan"),
+	}
+	for i := 0; i < 7; i++ {
+		date := today.AddDate(0, 0, i)
+		candidates = append(candidates, date.Format(parser.DateLayout)+"\t"+date.Weekday().String())
+	}
+
+	var completions []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, strings.ToLower(toComplete)) {
+			completions = append(completions, candidate)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStations offers the known station codes starting with the typed text, and the
+// names starting with it when no code does
+func completeStations(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return stationCompletions(completionStations(cmd), "", toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStationList completes the last station of a comma-separated --via list
+func completeStationList(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	done, last := "", toComplete
+	if i := strings.LastIndex(toComplete, ","); i >= 0 {
+		done, last = toComplete[:i+1], toComplete[i+1:]
+	}
+
+	completions := stationCompletions(completionStations(cmd), done, last)
+	if done == "" && strings.HasPrefix("auto", strings.ToLower(last)) {
+		completions = append(completions, "auto\tTop transit stations")
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStationNames offers the known station names starting with the typed text
+func completeStationNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	for _, station := range completionStations(cmd) {
+		if name, ok := completeName(station.Name, toComplete); ok {
+			completions = append(completions, name+"\t"+station.Code)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// stationCompletions returns "CODE\tNAME" entries for the codes starting with query, or
+// "name\tCODE" entries for the names starting with it when no code does, each prefixed with done
+// and in the case query was typed in
+func stationCompletions(known []stations.Station, done, query string) []string {
+	var codes, names []string
+	for _, station := range known {
+		if query == "" {
+			codes = append(codes, done+station.Code+"\t"+station.Name)
+		} else if code, ok := completeName(station.Code, query); ok {
+			codes = append(codes, done+code+"\t"+station.Name)
+		} else if name, ok := completeName(station.Name, query); ok {
+			names = append(names, done+name+"\t"+station.Code)
+		}
+	}
+	if len(codes) > 0 {
+		return codes
+	}
+	return names
+}
+
+// completeName reports whether name starts with the typed text, ignoring case, and returns the
+// name in the case it was typed in: shells filter candidates by case-sensitive prefix, so the typed
+// text is kept as is and the rest is lower-cased when the typed text ends in a lower-case letter
+func completeName(name, typed string) (string, bool) {
+	if typed == "" || len(typed) > len(name) || !strings.EqualFold(name[:len(typed)], typed) {
+		return "", false
+	}
+	rest := name[len(typed):]
+	if last, _ := utf8.DecodeLastRuneInString(typed); unicode.IsLower(last) {
+		rest = strings.ToLower(rest)
+	}
+	return typed + rest, true
+}
+
+// completionStations returns the known stations, sorted by code. Completion runs without the
+// persistent pre-run hooks, so the cache flags are applied here; failures complete nothing.
+func completionStations(cmd *cobra.Command) []stations.Station {
+	if err := configureCache(cmd, nil); err != nil {
+		return nil
+	}
+	directory, err := stations.Load(stations.Path(cache.Dir()))
+	if err != nil {
+		return nil
+	}
+	return directory.All()
+}
+
+// completeCachedURLs returns a completion hook offering the cached URLs of the given kinds,
+// or of every kind when none is given
+func completeCachedURLs(resources ...cache.Resource) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if err := configureCache(cmd, nil); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		entries, err := cache.List(cache.ActiveStore())
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var completions []string
+		for _, entry := range entries {
+			if entry.URL == "" || !strings.HasPrefix(entry.URL, toComplete) || !resourceWanted(entry.URL, resources) {
+				continue
+			}
+			completions = append(completions, entry.URL+"\t"+string(entry.Status))
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// resourceWanted reports whether url points to one of resources; no resources means any
+func resourceWanted(url string, resources []cache.Resource) bool {
+	if len(resources) == 0 {
+		return true
+	}
+	for _, resource := range resources {
+		if cache.ResourceOf(url) == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// firstArgOnly restricts a completion hook to the first positional argument
+func firstArgOnly(complete completionFunc) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, args, toComplete)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"trains/internal/stations"
+)
+
+// completions runs cobra's hidden completion command and returns the offered candidates
+func completions(t *testing.T, args ...string) []string {
+	t.Helper()
+
+	stdout := executeCommand(t, append([]string{"__complete"}, args...)...)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	// The last line is the completion directive, e.g. ":4"
+	return lines[:len(lines)-1]
+}
+
+func TestCompletion(t *testing.T) {
+	path := stations.Path(cacheSettings.Dir)
+	directory, err := stations.Load(path)
+	if err != nil {
+		t.Fatalf("failed to load station directory: %v", err)
+	}
+	directory.Add("KYN", "KALYAN JN")
+	directory.Add("PUNE", "PUNE JN")
+	if err := directory.Save(path); err != nil {
+		t.Fatalf("failed to save station directory: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"Station code", []string{"plan", "--from", "KY"}, "KYN\tKALYAN JN"},
+		{"Lower-case code", []string{"plan", "--to", "ky"}, "kyn\tKALYAN JN"},
+		{"Mixed-case name", []string{"doctor", "--to", "Kaly"}, "Kalyan jn\tKYN"},
+		{"Station name", []string{"doctor", "--from", "kaly"}, "kalyan jn\tKYN"},
+		{"Day", []string{"plan", "--day", "we"}, "wed\tWednesday"},
+		{"Search by name", []string{"stations", "search", "PU"}, "PUNE JN\tPUNE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completions(t, tt.args...)
+			if !slices.Contains(got, tt.want) {
+				t.Errorf("completions of %q = %q, want %q among them", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	// Completing viasearch flags through __complete would mark its flag groups required for
+	// the following tests, so the --via hook is called directly
+	if got, _ := completeStationList(viaSearchCmd, nil, "KYN,PU"); !slices.Equal(got, []string{"KYN,PUNE\tPUNE JN"}) {
+		t.Errorf("completions of --via KYN,PU = %q, want the last station completed", got)
+	}
+	if got, _ := completeStationList(viaSearchCmd, nil, "kyn,pu"); !slices.Equal(got, []string{"kyn,pune\tPUNE JN"}) {
+		t.Errorf("completions of --via kyn,pu = %q, want the last station completed in lower case", got)
+	}
+
+	if got := completions(t, "plan", "--day", "t"); !slices.Equal(got, []string{"tue\tTuesday", "tuesday\tTuesday", "thu\tThursday", "thursday\tThursday"}) {
+		t.Errorf("completions of --day t = %q, want Tuesday and Thursday inputs in week order", got)
+	}
+}
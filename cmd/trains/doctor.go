@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"trains/internal/cache"
+	"trains/internal/parser"
+)
+
+// runDoctor fetches sample pages and checks that their structure still matches the parsers
+func runDoctor(cmd *cobra.Command, args []string) error {
+	urls, err := cmd.Flags().GetStringSlice("url")
+	if err != nil {
+		return fmt.Errorf("error getting url flag: %v", err)
+	}
+	if len(urls) == 0 {
+		if urls, err = doctorSampleURLs(cmd); err != nil {
+			return err
+		}
+	}
+
+	fetcher, err := newFetcher()
+	if err != nil {
+		return err
+	}
+
+	broken := 0
+	for _, url := range urls {
+		var page string
+		var check func(string) []parser.Drift
+		switch cache.ResourceOf(url) {
+		case cache.ResourceTransit:
+			page, check = parser.PageTransit, parser.CheckTransitPage
+		case cache.ResourceTrain:
+			page, check = parser.PageTrains, parser.CheckTrainPage
+		default:
+			return fmt.Errorf("cannot check %s: not a transit or train listing URL", url)
+		}
+
+		content, err := fetcher.Fetch(url)
+		if err != nil {
+			return fmt.Errorf("error fetching %s: %v", url, err)
+		}
+
+		drifts := check(content)
+		if parser.DriftErr(page, drifts) != nil {
+			broken++
+		}
+		reportDoctorPage(page, url, content, drifts)
+	}
+
+	if broken > 0 {
+		return fmt.Errorf("%v: %d of %d pages cannot be parsed reliably", parser.ErrFormatChanged, broken, len(urls))
+	}
+	fmt.Printf("✅ All %d pages match the expected format\n", len(urls))
+	return nil
+}
+
+// doctorSampleURLs builds the transit and via-search URLs of the --from, --to and --via stations
+func doctorSampleURLs(cmd *cobra.Command) ([]string, error) {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	via, _ := cmd.Flags().GetString("via")
+
+	codes, err := resolveStations(loadStations(), []string{from, to, via})
+	if err != nil {
+		return nil, err
+	}
+	from, to, via = codes[0], codes[1], codes[2]
+
+	transitURL, err := parser.BuildTransitURL(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error building transit URL: %v", err)
+	}
+	viaURL, err := parser.BuildViaSearchURL(from, to, via)
+	if err != nil {
+		return nil, fmt.Errorf("error building via-search URL: %v", err)
+	}
+	return []string{parser.BuildPageURL(transitURL, 1), viaURL}, nil
+}
+
+// reportDoctorPage prints the result of checking one page, quoting the markup of every drift
+func reportDoctorPage(page, url, content string, drifts []parser.Drift) {
+	status := "✅"
+	for _, drift := range drifts {
+		if drift.Fatal {
+			status = "❌"
+			break
+		}
+		status = "⚠️ "
+	}
+
+	summary := ""
+	switch page {
+	case parser.PageTransit:
+		_, diagnostics := parser.ParseTransitRoutes(content)
+		summary = diagnostics.String()
+	case parser.PageTrains:
+		_, diagnostics, _ := parser.ParseTrainData(content)
+		summary = diagnostics.String()
+	}
+	fmt.Printf("%s %s page %s: %s\n", status, page, url, summary)
+
+	for _, drift := range drifts {
+		severity := "warning"
+		if drift.Fatal {
+			severity = "error"
+		}
+		fmt.Printf("   %s: %s\n", severity, drift.Problem)
+		if drift.Snippet != "" {
+			fmt.Printf("      %s\n", drift.Snippet)
+		}
+	}
+}
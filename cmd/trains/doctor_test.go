@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"trains/internal/client"
+)
+
+func TestDoctorNoCache(t *testing.T) {
+	const url = "https://etrain.info/trains/BL-to-NED-via-KYN"
+	replayTestdata(t, map[string]string{url: "trains_BL-to-NED-via-KYN.html"})
+
+	// Build the real fetcher chain to see what --no-cache selects, but serve the recorded page
+	replay := newFetcher
+	var built client.Fetcher
+	newFetcher = func() (client.Fetcher, error) {
+		var err error
+		if built, err = defaultFetcher(); err != nil {
+			return nil, err
+		}
+		return replay()
+	}
+	t.Cleanup(func() {
+		cacheEnabled = true
+		flag := rootCmd.PersistentFlags().Lookup("no-cache")
+		flag.Value.Set("false")
+		flag.Changed = false
+	})
+
+	stdout := executeCommand(t, "doctor", "--no-cache", "--url", url)
+	if !strings.Contains(stdout, "All 1 pages match") {
+		t.Errorf("doctor output = %q, want the page to match", stdout)
+	}
+
+	if built == nil {
+		t.Fatal("doctor did not build a fetcher")
+	}
+	if _, caching := built.(client.CachingFetcher); caching {
+		t.Errorf("doctor --no-cache fetched through %T, want the network fetcher", built)
+	}
+}
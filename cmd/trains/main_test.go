@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"trains/internal/client"
+	"trains/internal/output"
+)
+
+func TestMain(m *testing.M) {
+	// Keep the cache and station directory of test runs out of the user's cache directory
+	dir, err := os.MkdirTemp("", "trains-test-")
+	if err != nil {
+		panic(err)
+	}
+	cacheSettings.Dir = dir
+
+	initCommands()
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// replayTestdata replaces newFetcher with a ReplayFetcher serving files from testdata
+func replayTestdata(t *testing.T, pages map[string]string) {
+	t.Helper()
+
+	replay := client.ReplayFetcher{Pages: make(map[string]string, len(pages))}
+	for url, file := range pages {
+		content, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatalf("failed to read testdata %s: %v", file, err)
+		}
+		replay.Pages[url] = string(content)
+	}
+
+	previous := newFetcher
+	newFetcher = func() (client.Fetcher, error) { return replay, nil }
+	t.Cleanup(func() { newFetcher = previous })
+}
+
+// executeCommand runs the root command with args and returns what it wrote to stdout
+func executeCommand(t *testing.T, args ...string) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	// Drain the pipe concurrently so large outputs cannot block the command
+	captured := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		captured <- data
+	}()
+
+	rootCmd.SetArgs(args)
+	execErr := rootCmd.Execute()
+
+	writer.Close()
+	data := <-captured
+	if execErr != nil {
+		t.Fatalf("trains %v: unexpected error: %v", args, execErr)
+	}
+	return string(data)
+}
+
+func TestViaSearchReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/trains/BL-to-NED-via-KYN": "trains_BL-to-NED-via-KYN.html",
+	})
+
+	stdout := executeCommand(t, "viasearch", "--from", "BL", "--to", "NED", "--via", "KYN", "-o", "json")
+
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 1 {
+		t.Fatalf("got %d connections, want 1", len(connections))
+	}
+	if connections[0].Train1.Number != "11089" || connections[0].Train2.Number != "17617" {
+		t.Errorf("got connection %s + %s, want 11089 + 17617", connections[0].Train1.Number, connections[0].Train2.Number)
+	}
+	if connections[0].LayoverMinutes != 105 || connections[0].TotalMinutes != 1012 {
+		t.Errorf("got minutes (%d, %d), want (105, 1012)", connections[0].LayoverMinutes, connections[0].TotalMinutes)
+	}
+}
+
+func TestViaSearchByDateReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/trains/BL-to-NED-via-KYN": "trains_BL-to-NED-via-KYN.html",
+	})
+	t.Cleanup(func() {
+		flag := viaSearchCmd.Flags().Lookup("date")
+		flag.Value.Set("")
+		flag.Changed = false
+	})
+
+	// 11089 only leaves BL on Wednesdays
+	stdout := executeCommand(t, "viasearch", "--from", "BL", "--to", "NED", "--via", "KYN", "--date", "2026-11-04", "-o", "json")
+
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 1 {
+		t.Fatalf("got %d connections, want 1", len(connections))
+	}
+	leg1, leg2 := connections[0].Train1, connections[0].Train2
+	if leg1.DepartureAt == nil || leg2.ArrivalAt == nil {
+		t.Fatalf("legs have no timestamps: %s", stdout)
+	}
+	if got := leg1.DepartureAt.Format(time.RFC3339); got != "2026-11-04T01:08:00+05:30" {
+		t.Errorf("train1 departs at %s, want 2026-11-04T01:08:00+05:30", got)
+	}
+	if got := leg2.ArrivalAt.Format(time.RFC3339); got != "2026-11-04T18:00:00+05:30" {
+		t.Errorf("train2 arrives at %s, want 2026-11-04T18:00:00+05:30", got)
+	}
+
+	stdout = executeCommand(t, "viasearch", "--from", "BL", "--to", "NED", "--via", "KYN", "--date", "2026-11-03", "-o", "json")
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil || len(connections) != 0 {
+		t.Errorf("got %q on a Tuesday, want no connections", stdout)
+	}
+}
+
+func TestViaSearchListsSameDayFirst(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/trains/BL-to-NED-via-DD":  "trains_BL-to-NED-via-DD.html",
+		"https://etrain.info/trains/BL-to-NED-via-MMR": "trains_BL-to-NED-via-MMR.html",
+	})
+
+	// The overnight pair via MMR is faster, but viasearch lists same-day connections first
+	stdout := executeCommand(t, "viasearch", "--from", "BL", "--to", "NED", "--via", "DD,MMR", "-o", "json")
+
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 2 {
+		t.Fatalf("got %d connections, want 2", len(connections))
+	}
+	if connections[0].Train1.Number != "11077" || connections[1].Train1.Number != "12901" {
+		t.Errorf("got pairs starting with %s, %s, want 11077 (same day) before 12901 (next day)",
+			connections[0].Train1.Number, connections[1].Train1.Number)
+	}
+}
+
+func TestTopSearchReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/transit/BL-NED?page=1": "transit_BL-NED.html",
+	})
+
+	stdout := executeCommand(t, "topsearch", "--url", "https://etrain.info/transit/BL-NED?page=1", "-o", "json", "--max-distance", "900")
+
+	var routes []output.TransitRoute
+	if err := json.Unmarshal([]byte(stdout), &routes); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want 2", len(routes))
+	}
+	if routes[0].TransitStationCode != "KYN" || routes[1].TransitStationCode != "MMR" {
+		t.Errorf("got routes via %s, %s, want KYN, MMR", routes[0].TransitStationCode, routes[1].TransitStationCode)
+	}
+}
+
+func TestViaSearchReplayFlag(t *testing.T) {
+	t.Cleanup(func() { replayDir = "" })
+
+	stdout := executeCommand(t, "viasearch", "--from", "BL", "--to", "NED", "--via", "KYN",
+		"--replay", filepath.Join("..", "..", "internal", "parser", "testdata", "bl-ned"), "-o", "ndjson")
+
+	var connection output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connection); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if connection.Train1.Number != "11089" || connection.Train2.Number != "17617" {
+		t.Errorf("got connection %s + %s, want 11089 + 17617", connection.Train1.Number, connection.Train2.Number)
+	}
+}
+
+func TestTopSearchAllPagesReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/transit/BL-NED?page=1": "transit_BL-NED_page1.html",
+		"https://etrain.info/transit/BL-NED?page=2": "transit_BL-NED_page2.html",
+		"https://etrain.info/transit/BL-NED?page=3": "transit_BL-NED_page3.html",
+	})
+
+	// Page 1 only links to page 2; page 3 is discovered from page 2's pagination
+	stdout := executeCommand(t, "topsearch", "--url", "https://etrain.info/transit/BL-NED", "-o", "json", "--max-distance", "0")
+
+	var routes []output.TransitRoute
+	if err := json.Unmarshal([]byte(stdout), &routes); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(routes) != 4 {
+		t.Fatalf("got %d routes, want 4", len(routes))
+	}
+	// Multi-page results are sorted by distance
+	if routes[0].TransitStationCode != "DD" || routes[3].TransitStationCode != "PUNE" {
+		t.Errorf("got first/last routes via %s/%s, want DD/PUNE", routes[0].TransitStationCode, routes[3].TransitStationCode)
+	}
+}
+
+func TestViaSearchAutoReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/transit/BL-NED?page=1":    "transit_BL-NED_page1.html",
+		"https://etrain.info/transit/BL-NED?page=2":    "transit_BL-NED_page2.html",
+		"https://etrain.info/transit/BL-NED?page=3":    "transit_BL-NED_page3.html",
+		"https://etrain.info/trains/BL-to-NED-via-DD":  "trains_BL-to-NED-via-DD.html",
+		"https://etrain.info/trains/BL-to-NED-via-KYN": "trains_BL-to-NED-via-KYN.html",
+	})
+
+	// The two shortest transit routes are via DD (701 km) and KYN (754 km)
+	stdout := executeCommand(t, "viasearch", "--from", "BL", "--to", "NED", "--via", "auto", "--via-top", "2", "-o", "json")
+
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 2 {
+		t.Fatalf("got %d connections, want 2", len(connections))
+	}
+	// Merged connections are ranked by total journey time
+	if connections[0].Train1.To != "DD" || connections[1].Train1.To != "KYN" {
+		t.Errorf("got connections via %s, %s, want DD, KYN", connections[0].Train1.To, connections[1].Train1.To)
+	}
+	if connections[0].TotalMinutes > connections[1].TotalMinutes {
+		t.Errorf("connections not ranked by total time: %d > %d", connections[0].TotalMinutes, connections[1].TotalMinutes)
+	}
+}
+
+func TestPlanReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/transit/BL-NED?page=1":                                    "transit_BL-NED_page1.html",
+		"https://etrain.info/transit/BL-NED?page=2":                                    "transit_BL-NED_page2.html",
+		"https://etrain.info/transit/BL-NED?page=3":                                    "transit_BL-NED_page3.html",
+		"https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Daund-Jn-DD":   "trains_BL-to-NED-via-DD.html",
+		"https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN": "trains_BL-to-NED-via-KYN.html",
+	})
+
+	// Follows the ShowLinks of the two shortest transit routes and keeps the best pair
+	stdout := executeCommand(t, "plan", "--from", "BL", "--to", "NED", "--routes", "2", "--limit", "1", "-o", "json")
+
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 1 {
+		t.Fatalf("got %d connections, want 1", len(connections))
+	}
+	if connections[0].Train1.Number != "11077" || connections[0].Train2.Number != "17613" {
+		t.Errorf("got best pair %s + %s, want 11077 + 17613", connections[0].Train1.Number, connections[0].Train2.Number)
+	}
+}
+
+func TestPlanRanksNextDayConnectionsByTotalTime(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/transit/BL-NED?page=1":                                    "transit_BL-NED_page1.html",
+		"https://etrain.info/transit/BL-NED?page=2":                                    "transit_BL-NED_page2.html",
+		"https://etrain.info/transit/BL-NED?page=3":                                    "transit_BL-NED_page3.html",
+		"https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Daund-Jn-DD":   "trains_BL-to-NED-via-DD.html",
+		"https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN": "trains_BL-to-NED-via-KYN.html",
+		"https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Manmad-Jn-MMR": "trains_BL-to-NED-via-MMR.html",
+	})
+
+	// The overnight pair via MMR connects the next day but is faster than every same-day pair
+	stdout := executeCommand(t, "plan", "--from", "BL", "--to", "NED", "--routes", "3", "--limit", "2", "-o", "json")
+
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 2 {
+		t.Fatalf("got %d connections, want 2", len(connections))
+	}
+	if connections[0].Train1.Number != "12901" || connections[1].Train1.Number != "11077" {
+		t.Errorf("got pairs starting with %s, %s, want 12901 (next day, 12h) before 11077 (same day, 15h)",
+			connections[0].Train1.Number, connections[1].Train1.Number)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"trains/internal/client"
+	"trains/internal/parser"
+	"trains/internal/types"
+)
+
+// transitPage is the parsed form of one transit listing page
+type transitPage struct {
+	Routes      []types.TransitRoute `json:"routes"`
+	LastPage    int                  `json:"last_page"` // Highest page number linked from the page
+	Diagnostics parser.Diagnostics   `json:"diagnostics"`
+}
+
+// parseTransitPage parses the routes and pagination of a transit listing page
+func parseTransitPage(url, content string) (transitPage, error) {
+	if err := checkDrift(url, parser.PageTransit, parser.CheckTransitPage(content)); err != nil {
+		return transitPage{}, err
+	}
+
+	routes, diagnostics := parser.ParseTransitRoutes(content)
+	return transitPage{
+		Routes:      routes,
+		LastPage:    parser.ParseLastPageNumber(content),
+		Diagnostics: diagnostics,
+	}, nil
+}
+
+// trainPage is the parsed form of one via-search page
+type trainPage struct {
+	Trains      []types.TrainData  `json:"trains"`
+	Diagnostics parser.Diagnostics `json:"diagnostics"`
+}
+
+// parseTrainPage parses the trains listed on a via-search page
+func parseTrainPage(url, content string) (trainPage, error) {
+	if err := checkDrift(url, parser.PageTrains, parser.CheckTrainPage(content)); err != nil {
+		return trainPage{}, err
+	}
+
+	trains, diagnostics, err := parser.ParseTrainData(content)
+	return trainPage{Trains: trains, Diagnostics: diagnostics}, err
+}
+
+// checkDrift logs harmless format drift of the page at url and returns an error for fatal drift,
+// so that a changed page is not mistaken for one without results
+func checkDrift(url, page string, drifts []parser.Drift) error {
+	for _, drift := range drifts {
+		if !drift.Fatal {
+			slog.Warn("⚠️  Page format drift", "url", url, "problem", drift.Problem, "snippet", drift.Snippet)
+		}
+	}
+	return parser.DriftErr(page, drifts)
+}
+
+// reportRowErrors logs every row a parser had to skip on the page described by label
+func reportRowErrors(label string, diagnostics parser.Diagnostics) {
+	for _, rowErr := range diagnostics.Errors {
+		slog.Warn("⚠️  Skipped "+label+" row", "row", rowErr.Row, "reason", rowErr.Reason, "detail", rowErr.Detail)
+	}
+}
+
+// fetchTransitPages returns the parsed transit listing pages at urls, in input order,
+// and adds their stations to the station directory
+func fetchTransitPages(fetcher client.Fetcher, urls []string, concurrency int) ([]transitPage, error) {
+	pages, err := fetchParsed(fetcher, urls, concurrency, parseTransitPage)
+	if err != nil {
+		return nil, err
+	}
+	recordStations(pages)
+	return pages, nil
+}
+
+// fetchTrainPages returns the parsed via-search pages at urls, in input order
+func fetchTrainPages(fetcher client.Fetcher, urls []string, concurrency int) ([]trainPage, error) {
+	return fetchParsed(fetcher, urls, concurrency, parseTrainPage)
+}
+
+// fetchParsed returns the parse results of the pages at urls. Results cached by the current
+// parser version are used as they are; only the remaining pages are fetched and parsed.
+// A page that fails to parse fails the whole call and is not cached.
+func fetchParsed[T any](fetcher client.Fetcher, urls []string, concurrency int, parse func(url, content string) (T, error)) ([]T, error) {
+	results := make([]T, len(urls))
+
+	// Only a caching fetcher offers parse results, so record and replay always see every page
+	parsedCache, _ := fetcher.(client.ParsedCache)
+
+	var missing []int
+	var missingURLs []string
+	for i, url := range urls {
+		if parsedCache != nil && parsedCache.LoadParsed(url, parser.Version, &results[i]) {
+			continue
+		}
+		missing = append(missing, i)
+		missingURLs = append(missingURLs, url)
+	}
+
+	contents, err := client.FetchAll(fetcher, missingURLs, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	for j, content := range contents {
+		i := missing[j]
+		if results[i], err = parse(urls[i], content); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", urls[i], err)
+		}
+		if parsedCache != nil {
+			parsedCache.SaveParsed(urls[i], parser.Version, results[i])
+		}
+	}
+	return results, nil
+}
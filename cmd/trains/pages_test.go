@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"trains/internal/logging"
+	"trains/internal/parser"
+)
+
+// memoryParsedCache is a fetcher with an in-memory parse cache that counts network fetches
+type memoryParsedCache struct {
+	pages   map[string]string
+	parsed  map[string]transitPage
+	fetches int
+}
+
+func (m *memoryParsedCache) Fetch(url string) (string, error) {
+	m.fetches++
+	content, ok := m.pages[url]
+	if !ok {
+		return "", fmt.Errorf("no page for %s", url)
+	}
+	return content, nil
+}
+
+func (m *memoryParsedCache) LoadParsed(url string, version int, v any) bool {
+	page, ok := m.parsed[fmt.Sprintf("%s@%d", url, version)]
+	if ok {
+		*(v.(*transitPage)) = page
+	}
+	return ok
+}
+
+func (m *memoryParsedCache) SaveParsed(url string, version int, v any) {
+	m.parsed[fmt.Sprintf("%s@%d", url, version)] = v.(transitPage)
+}
+
+func TestFetchTransitPagesUsesParseCache(t *testing.T) {
+	const url = "https://etrain.info/transit/BL-NED?page=1"
+	content, err := os.ReadFile(filepath.Join("testdata", "transit_BL-NED_page1.html"))
+	if err != nil {
+		t.Fatalf("failed to read testdata: %v", err)
+	}
+	fetcher := &memoryParsedCache{
+		pages:  map[string]string{url: string(content)},
+		parsed: map[string]transitPage{},
+	}
+
+	first, err := fetchTransitPages(fetcher, []string{url}, 1)
+	if err != nil {
+		t.Fatalf("fetchTransitPages failed: %v", err)
+	}
+	second, err := fetchTransitPages(fetcher, []string{url}, 1)
+	if err != nil {
+		t.Fatalf("fetchTransitPages failed: %v", err)
+	}
+
+	if fetcher.fetches != 1 {
+		t.Errorf("page fetched %d times, want 1 (second call should use the parse cache)", fetcher.fetches)
+	}
+	if len(first[0].Routes) == 0 || len(second[0].Routes) != len(first[0].Routes) || second[0].LastPage != first[0].LastPage {
+		t.Errorf("cached parse results differ: first %+v, second %+v", first[0], second[0])
+	}
+	if _, ok := fetcher.parsed[fmt.Sprintf("%s@%d", url, parser.Version)]; !ok {
+		t.Error("parse results were not saved under the current parser version")
+	}
+}
+
+func TestFetchTransitPagesReportsDrift(t *testing.T) {
+	const url = "https://etrain.info/transit/BL-NED?page=1"
+	fetcher := &memoryParsedCache{
+		pages:  map[string]string{url: `<html><body><div class="routes">No table here</div></body></html>`},
+		parsed: map[string]transitPage{},
+	}
+
+	_, err := fetchTransitPages(fetcher, []string{url}, 1)
+	if !errors.Is(err, parser.ErrFormatChanged) {
+		t.Fatalf("fetchTransitPages() error = %v, want upstream format changed", err)
+	}
+	if len(fetcher.parsed) != 0 {
+		t.Error("results of a drifted page were cached")
+	}
+}
+
+func TestFetchAllTransitPagesStopsAtLimit(t *testing.T) {
+	const baseURL = "https://etrain.info/transit/BL-NED"
+	const linkedPages = maxTransitPages + 10
+
+	// Every page links the whole pagination, so the limit is hit on every pass
+	fetcher := &memoryParsedCache{pages: map[string]string{}, parsed: map[string]transitPage{}}
+	for pageNum := 1; pageNum <= linkedPages; pageNum++ {
+		fetcher.pages[parser.BuildPageURL(baseURL, pageNum)] = fmt.Sprintf(`<html><body>
+<table class="transit">
+<tr><th>From</th><th>Trains</th><th>Via</th><th>Trains</th><th>To</th><th>Distance</th></tr>
+<tr><td>VALSAD<br>(BL)</td><td>1</td><td><a href="/trains/BL-to-NED-via-KYN">Show</a> KALYAN JN<br>(KYN)</td><td>1</td><td>H SAHIB NANDED<br>(NED)</td><td>%d Kms</td></tr>
+</table>
+<ul class="pagination"><li><a href="/transit/BL-NED?page=%d">%d</a></li></ul>
+</body></html>`, 700+pageNum, linkedPages, linkedPages)
+	}
+
+	var logs bytes.Buffer
+	logging.Setup(&logs, slog.LevelWarn, logging.FormatText)
+	t.Cleanup(func() { logging.Setup(os.Stderr, slog.LevelInfo, logging.FormatText) })
+
+	routes, err := fetchAllTransitPages(baseURL, fetcher, 4)
+	if err != nil {
+		t.Fatalf("fetchAllTransitPages failed: %v", err)
+	}
+	if len(routes) != maxTransitPages || fetcher.fetches != maxTransitPages {
+		t.Errorf("got %d routes from %d fetches, want %d of each", len(routes), fetcher.fetches, maxTransitPages)
+	}
+	if warnings := strings.Count(logs.String(), "Pagination links"); warnings != 1 {
+		t.Errorf("page limit warned %d times, want once:\n%s", warnings, logs.String())
+	}
+}
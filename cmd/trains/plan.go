@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"trains/internal/logging"
+	"trains/internal/output"
+	"trains/internal/parser"
+)
+
+// runPlan handles the plan command
+func runPlan(cmd *cobra.Command, args []string) error {
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+
+	// Stations may be given by name; the station directory turns them into codes
+	endpoints, err := resolveStations(loadStations(), []string{from, to})
+	if err != nil {
+		return err
+	}
+	from, to = endpoints[0], endpoints[1]
+
+	// Get routes flag
+	routeCount, err := cmd.Flags().GetInt("routes")
+	if err != nil {
+		return fmt.Errorf("error getting routes flag: %v", err)
+	}
+	if routeCount < 1 {
+		return fmt.Errorf("invalid routes %d: must be at least 1", routeCount)
+	}
+
+	// Get sort, limit and pareto flags
+	ranking, err := getRankingOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Get day or date filter
+	travel, err := getTravelDay(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Get layover and journey constraints
+	constraints, err := getConstraints(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Validate output format
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	logging.Infof("🚂 Planning journeys from %s to %s...", strings.ToUpper(from), strings.ToUpper(to))
+	logging.Infof("💾 Cache: %t", cacheEnabled)
+	logging.Infof("🛤️  Transit routes to follow: %d", routeCount)
+	if travel.Day != "" {
+		logging.Infof("📅 Day Filter: %s", travel)
+	}
+	logging.Separator()
+
+	// Build the fetcher chain from the global flags
+	fetcher, err := newFetcher()
+	if err != nil {
+		return err
+	}
+
+	// Step 1: discover transit routes like topsearch does
+	transitRoutes, err := discoverTransitRoutes(fetcher, from, to, routeCount)
+	if err != nil {
+		return err
+	}
+
+	// Step 2: follow each route's ShowLink through the viasearch pipeline
+	routes := make([]viaRoute, 0, len(transitRoutes))
+	transitStations := make([]string, 0, len(transitRoutes))
+	for _, transitRoute := range transitRoutes {
+		url := parser.EtrainBaseURL + transitRoute.ShowLink
+		sourceStation, destinationStation, transitStation, err := parser.ExtractRouteInfo(url)
+		if err != nil {
+			logging.Warnf("⚠️  Skipping transit route via %s: %v", transitRoute.TransitStationCode, err)
+			continue
+		}
+		routes = append(routes, viaRoute{URL: url, SourceStation: sourceStation, DestinationStation: destinationStation, TransitStation: transitStation})
+		transitStations = append(transitStations, transitStation)
+	}
+	if len(routes) == 0 {
+		return fmt.Errorf("no usable transit routes found between %s and %s", from, to)
+	}
+
+	// Step 3: merge all connections and rank them
+	connections, err := searchViaRoutes(fetcher, routes, travel.Day, constraints)
+	if err != nil {
+		return err
+	}
+	if !travel.Date.IsZero() {
+		scheduleConnections(connections, travel.Date)
+	}
+	connections, found := rankConnections(connections, ranking)
+
+	// Generate results
+	if format != output.FormatText {
+		return output.WriteConnections(os.Stdout, format, connections)
+	}
+	generateConnections(connections, found, travel.String(), constraints, routes[0].SourceStation, routes[0].DestinationStation, strings.Join(transitStations, ", "))
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"trains/internal/parser"
+	"trains/internal/types"
+)
+
+// sortKey selects how connections are ranked
+type sortKey string
+
+const (
+	sortByTotal     sortKey = "total"     // Shortest total journey time first
+	sortByLayover   sortKey = "layover"   // Shortest layover first
+	sortByDeparture sortKey = "departure" // Earliest departure from the source station first
+	sortByArrival   sortKey = "arrival"   // Earliest arrival at the destination first
+	sortByScore     sortKey = "score"     // Lowest combined score first (see connectionScore)
+)
+
+// sortKeys lists the valid sort keys in help order
+var sortKeys = []sortKey{sortByTotal, sortByLayover, sortByDeparture, sortByArrival, sortByScore}
+
+// parseSortKey validates and normalizes a sort key
+func parseSortKey(name string) (sortKey, error) {
+	key := sortKey(strings.ToLower(strings.TrimSpace(name)))
+	for _, valid := range sortKeys {
+		if key == valid {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("invalid sort key '%s'. Valid options: total, layover, departure, arrival, score", name)
+}
+
+// departureMinutes returns the departure time from the source station in minutes since midnight
+func departureMinutes(conn types.RouteConnection) int {
+	return parser.ParseTime(conn.Train1.SourceTime)
+}
+
+// arrivalMinutes returns the arrival time at the destination in minutes since midnight of the departure day
+func arrivalMinutes(conn types.RouteConnection) int {
+	return departureMinutes(conn) + conn.TotalMinutes
+}
+
+// connectionScore combines journey and waiting time; the layover counts twice since waiting
+// at a platform is worse than travelling
+func connectionScore(conn types.RouteConnection) int {
+	return conn.TotalMinutes + conn.LayoverMinutes
+}
+
+// isSameDayConnection reports whether train2 leaves on the day train1 reaches the transit station
+func isSameDayConnection(conn types.RouteConnection) bool {
+	return conn.TransitDepartureDay == conn.TransitArrivalDay
+}
+
+// sortConnections ranks connections by key, with same-day connections before later ones if groupSameDay is set
+func sortConnections(connections []types.RouteConnection, key sortKey, groupSameDay bool) {
+	value := map[sortKey]func(types.RouteConnection) int{
+		sortByTotal:     func(conn types.RouteConnection) int { return conn.TotalMinutes },
+		sortByLayover:   func(conn types.RouteConnection) int { return conn.LayoverMinutes },
+		sortByDeparture: departureMinutes,
+		sortByArrival:   arrivalMinutes,
+		sortByScore:     connectionScore,
+	}[key]
+
+	sort.SliceStable(connections, func(i, j int) bool {
+		sameDayI, sameDayJ := isSameDayConnection(connections[i]), isSameDayConnection(connections[j])
+		if groupSameDay && sameDayI != sameDayJ {
+			return sameDayI
+		}
+		if valueI, valueJ := value(connections[i]), value(connections[j]); valueI != valueJ {
+			return valueI < valueJ
+		}
+		// Fall back to total time so ties are ranked consistently
+		return connections[i].TotalMinutes < connections[j].TotalMinutes
+	})
+}
+
+// paretoCriterion is one dimension a connection can beat another on
+type paretoCriterion struct {
+	Name        string                          // Label stored in RouteConnection.BestFor
+	Description string                          // Human-readable form for text output
+	Cost        func(types.RouteConnection) int // Lower is better
+}
+
+// paretoCriteria are the trade-offs travellers choose between
+var paretoCriteria = []paretoCriterion{
+	{"departure", "latest departure", func(conn types.RouteConnection) int { return -departureMinutes(conn) }},
+	{"arrival", "earliest arrival", arrivalMinutes},
+	{"total", "shortest journey", func(conn types.RouteConnection) int { return conn.TotalMinutes }},
+	{"layover", "shortest layover", func(conn types.RouteConnection) int { return conn.LayoverMinutes }},
+}
+
+// paretoBalanced marks a Pareto-optimal connection that is not the best on any single criterion
+const paretoBalanced = "balanced"
+
+// dominates reports whether a is at least as good as b on every criterion and better on one
+func dominates(a, b types.RouteConnection) bool {
+	better := false
+	for _, criterion := range paretoCriteria {
+		costA, costB := criterion.Cost(a), criterion.Cost(b)
+		if costA > costB {
+			return false
+		}
+		if costA < costB {
+			better = true
+		}
+	}
+	return better
+}
+
+// paretoFront keeps the connections no other connection dominates and sets their BestFor criteria
+func paretoFront(connections []types.RouteConnection) []types.RouteConnection {
+	front := make([]types.RouteConnection, 0, len(connections))
+	for i, candidate := range connections {
+		dominated := false
+		for j, other := range connections {
+			if i != j && dominates(other, candidate) {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			front = append(front, candidate)
+		}
+	}
+
+	// A connection wins a criterion when nothing in the front beats it there; ties share the win
+	for i := range front {
+		front[i].BestFor = nil
+		for _, criterion := range paretoCriteria {
+			best := true
+			for _, other := range front {
+				if criterion.Cost(other) < criterion.Cost(front[i]) {
+					best = false
+					break
+				}
+			}
+			if best {
+				front[i].BestFor = append(front[i].BestFor, criterion.Name)
+			}
+		}
+		if len(front[i].BestFor) == 0 {
+			front[i].BestFor = []string{paretoBalanced}
+		}
+	}
+
+	return front
+}
+
+// describeBestFor turns BestFor criteria into text such as "latest departure, shortest layover"
+func describeBestFor(bestFor []string) string {
+	descriptions := make([]string, 0, len(bestFor))
+	for _, name := range bestFor {
+		description := "balanced trade-off"
+		for _, criterion := range paretoCriteria {
+			if criterion.Name == name {
+				description = criterion.Description
+			}
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, ", ")
+}
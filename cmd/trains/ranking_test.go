@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"trains/internal/types"
+)
+
+// testConnection builds a connection for ranking tests
+func testConnection(number, departure string, layover, total, arrivalDay, departureDay int) types.RouteConnection {
+	return types.RouteConnection{
+		Train1:              types.TrainData{Number: number, SourceTime: departure},
+		LayoverMinutes:      layover,
+		TotalMinutes:        total,
+		TransitArrivalDay:   arrivalDay,
+		TransitDepartureDay: departureDay,
+	}
+}
+
+func TestSortConnections(t *testing.T) {
+	connections := []types.RouteConnection{
+		testConnection("A", "10:00", 200, 900, 0, 0),
+		testConnection("E", "07:00", 90, 1000, 0, 1), // Ties with B on layover, total and score, but next day
+		testConnection("B", "06:00", 90, 1000, 0, 0),
+		testConnection("C", "22:00", 60, 600, 0, 1), // Fastest, but next day
+		testConnection("D", "08:00", 150, 800, 0, 0),
+	}
+
+	tests := []struct {
+		key          sortKey
+		groupSameDay bool
+		want         string
+	}{
+		// Same-day connections first, then each group by key
+		{sortByTotal, true, "DABCE"},
+		{sortByLayover, true, "BDACE"},
+		{sortByDeparture, true, "BDAEC"},
+		{sortByArrival, true, "DBAEC"}, // 08:00+800=1280, 06:00+1000=1360, 10:00+900=1500 | 07:00+1000=1420, 22:00+600=1920
+		{sortByScore, true, "DBACE"},   // 950, 1090, 1100 | 660, 1090
+
+		// Purely by key; ties keep their order, so E stays before B
+		{sortByTotal, false, "CDAEB"},
+		{sortByLayover, false, "CEBDA"},
+		{sortByDeparture, false, "BEDAC"},
+		{sortByArrival, false, "DBEAC"},
+		{sortByScore, false, "CDEBA"},
+	}
+
+	for _, tt := range tests {
+		name := string(tt.key)
+		if tt.groupSameDay {
+			name += "/same day first"
+		}
+		t.Run(name, func(t *testing.T) {
+			sorted := append([]types.RouteConnection(nil), connections...)
+			sortConnections(sorted, tt.key, tt.groupSameDay)
+
+			got := ""
+			for _, conn := range sorted {
+				got += conn.Train1.Number
+			}
+			if got != tt.want {
+				t.Errorf("sortConnections(%s, %t) = %s, want %s", tt.key, tt.groupSameDay, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	if key, err := parseSortKey(" Arrival "); err != nil || key != sortByArrival {
+		t.Errorf("parseSortKey(Arrival) = %q, %v, want %q", key, err, sortByArrival)
+	}
+	if _, err := parseSortKey("fastest"); err == nil {
+		t.Error("parseSortKey(fastest) should fail")
+	}
+}
+
+func TestParetoFront(t *testing.T) {
+	connections := []types.RouteConnection{
+		testConnection("A", "06:00", 60, 600, 0, 0),  // Fastest, shortest layover, earliest arrival (16:00)
+		testConnection("B", "06:00", 120, 700, 0, 0), // Dominated by A
+		testConnection("C", "12:00", 180, 720, 0, 0), // Latest departure, arrives 00:00
+		testConnection("D", "09:00", 90, 660, 0, 0),  // Trade-off between A and C, arrives 20:00
+		testConnection("E", "09:00", 90, 800, 0, 0),  // Dominated by D
+	}
+
+	front := paretoFront(connections)
+
+	want := map[string]string{"A": "arrival,total,layover", "C": "departure", "D": "balanced"}
+	if len(front) != len(want) {
+		t.Fatalf("paretoFront kept %d connections, want %d", len(front), len(want))
+	}
+	for _, conn := range front {
+		got := strings.Join(conn.BestFor, ",")
+		if got != want[conn.Train1.Number] {
+			t.Errorf("connection %s BestFor = %q, want %q", conn.Train1.Number, got, want[conn.Train1.Number])
+		}
+	}
+}
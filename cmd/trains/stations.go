@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"trains/internal/cache"
+	"trains/internal/logging"
+	"trains/internal/output"
+	"trains/internal/stations"
+)
+
+// chooseStation asks the user to pick one of the candidates of an ambiguous station;
+// tests replace it to simulate a terminal
+var chooseStation = promptStation
+
+// loadStations reads the station directory, falling back to an empty one with a warning
+func loadStations() *stations.Directory {
+	directory, err := stations.Load(stations.Path(cache.Dir()))
+	if err != nil {
+		logging.Warnf("⚠️  Ignoring station directory: %v", err)
+		return stations.New()
+	}
+	return directory
+}
+
+// recordStations adds the stations of parsed transit pages to the station directory
+func recordStations(pages []transitPage) {
+	directory := loadStations()
+	added := 0
+	for _, page := range pages {
+		added += directory.AddRoutes(page.Routes)
+	}
+	if added == 0 {
+		return
+	}
+
+	if err := directory.Save(stations.Path(cache.Dir())); err != nil {
+		logging.Warnf("⚠️  Failed to save station directory: %v", err)
+		return
+	}
+	logging.Debugf("🚉 Learned %d station names", added)
+}
+
+// resolveStation turns a station code or name into a code, asking the user to choose when
+// the name is ambiguous
+func resolveStation(directory *stations.Directory, query string) (string, error) {
+	station, err := directory.Resolve(query)
+
+	var ambiguous *stations.AmbiguousError
+	if errors.As(err, &ambiguous) {
+		station, err = chooseStation(ambiguous)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if station.Name != "" && !strings.EqualFold(strings.TrimSpace(query), station.Code) {
+		logging.Infof("🚉 %s → %v", query, station)
+	}
+	return station.Code, nil
+}
+
+// resolveStations resolves every station of a list
+func resolveStations(directory *stations.Directory, queries []string) ([]string, error) {
+	codes := make([]string, 0, len(queries))
+	for _, query := range queries {
+		code, err := resolveStation(directory, query)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// promptStation lists the candidates on stderr and reads the choice from stdin. Without a
+// terminal to ask on, the ambiguity is an error listing the candidates.
+func promptStation(ambiguous *stations.AmbiguousError) (stations.Station, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return stations.Station{}, ambiguous
+	}
+
+	fmt.Fprintf(os.Stderr, "❓ %q matches several stations:\n", ambiguous.Query)
+	for i, candidate := range ambiguous.Candidates {
+		fmt.Fprintf(os.Stderr, "  %d. %-5s %s\n", i+1, candidate.Code, candidate.Name)
+	}
+	fmt.Fprintf(os.Stderr, "Choose a station [1-%d]: ", len(ambiguous.Candidates))
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return stations.Station{}, fmt.Errorf("error reading station choice: %v", err)
+	}
+	choice, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || choice < 1 || choice > len(ambiguous.Candidates) {
+		return stations.Station{}, fmt.Errorf("invalid choice %q: enter a number from 1 to %d", strings.TrimSpace(line), len(ambiguous.Candidates))
+	}
+	return ambiguous.Candidates[choice-1].Station, nil
+}
+
+// runStationsSearch handles the stations search command
+func runStationsSearch(cmd *cobra.Command, args []string) error {
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return fmt.Errorf("error getting limit flag: %v", err)
+	}
+
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	directory := loadStations()
+	matches := directory.Search(strings.Join(args, " "))
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	found := make([]stations.Station, 0, len(matches))
+	for _, match := range matches {
+		found = append(found, match.Station)
+	}
+	return writeStations(format, found, directory.Len())
+}
+
+// runStationsList handles the stations list command
+func runStationsList(cmd *cobra.Command, args []string) error {
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	directory := loadStations()
+	return writeStations(format, directory.All(), directory.Len())
+}
+
+// writeStations prints stations as "CODE  NAME" lines or in a machine-readable format
+func writeStations(format output.Format, found []stations.Station, known int) error {
+	if format != output.FormatText {
+		return output.WriteStations(os.Stdout, format, found)
+	}
+
+	if len(found) == 0 {
+		if known == 0 {
+			fmt.Println("No stations known yet. Station names are collected from topsearch and plan results.")
+		} else {
+			fmt.Printf("No matching stations among %d known.\n", known)
+		}
+		return nil
+	}
+	for _, station := range found {
+		fmt.Printf("%-5s  %s\n", station.Code, station.Name)
+	}
+	return nil
+}
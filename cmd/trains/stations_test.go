@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"trains/internal/output"
+	"trains/internal/stations"
+)
+
+func TestStationNamesReplay(t *testing.T) {
+	replayTestdata(t, map[string]string{
+		"https://etrain.info/transit/BL-NED?page=1":    "transit_BL-NED.html",
+		"https://etrain.info/trains/BL-to-NED-via-KYN": "trains_BL-to-NED-via-KYN.html",
+	})
+
+	// Station names are learned from the transit page...
+	executeCommand(t, "topsearch", "--url", "https://etrain.info/transit/BL-NED?page=1", "-o", "json")
+
+	var found []output.Station
+	if err := json.Unmarshal([]byte(executeCommand(t, "stations", "search", "nanded", "-o", "json")), &found); err != nil {
+		t.Fatalf("failed to decode stations: %v", err)
+	}
+	if len(found) != 1 || found[0].Code != "NED" {
+		t.Errorf("stations search nanded = %+v, want NED", found)
+	}
+
+	// ...and accepted wherever a station code is
+	stdout := executeCommand(t, "viasearch", "--from", "valsad", "--to", "h sahib nanded", "--via", "kalyan", "-o", "json")
+	var connections []output.Connection
+	if err := json.Unmarshal([]byte(stdout), &connections); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout, err)
+	}
+	if len(connections) != 1 {
+		t.Errorf("got %d connections, want 1", len(connections))
+	}
+}
+
+func TestResolveAmbiguousStation(t *testing.T) {
+	directory := stations.New()
+	directory.Add("KYN", "KALYAN JUNCTION")
+	directory.Add("PUNE", "PUNE JUNCTION")
+
+	// Without a terminal the candidates are reported in the error
+	_, err := resolveStation(directory, "junction")
+	var ambiguous *stations.AmbiguousError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("resolveStation(junction) error = %v, want 2 candidates", err)
+	}
+
+	previous := chooseStation
+	chooseStation = func(ambiguous *stations.AmbiguousError) (stations.Station, error) {
+		return ambiguous.Candidates[1].Station, nil
+	}
+	t.Cleanup(func() { chooseStation = previous })
+
+	code, err := resolveStation(directory, "junction")
+	if err != nil || code != "KYN" {
+		t.Errorf("resolveStation(junction) with a choice = %q, %v; want KYN", code, err)
+	}
+}
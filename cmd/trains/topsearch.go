@@ -2,16 +2,20 @@ package main
 
 import (
 	"fmt"
-	"strings"
+	"os"
 
 	"github.com/spf13/cobra"
 
-	"trains/internal/cache"
 	"trains/internal/client"
+	"trains/internal/logging"
+	"trains/internal/output"
 	"trains/internal/parser"
 	"trains/internal/types"
 )
 
+// maxTransitPages is a safety limit for runaway pagination
+const maxTransitPages = 50
+
 // runTopSearch handles the topsearch command
 func runTopSearch(cmd *cobra.Command, args []string) error {
 	// Get URL flag
@@ -19,149 +23,136 @@ func runTopSearch(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("error getting url flag: %v", err)
 	}
-	
+
 	// Get limit flag
 	limit, err := cmd.Flags().GetInt("limit")
 	if err != nil {
 		return fmt.Errorf("error getting limit flag: %v", err)
 	}
-	
+
 	// Get max-distance flag
 	maxDistance, err := cmd.Flags().GetInt("max-distance")
 	if err != nil {
 		return fmt.Errorf("error getting max-distance flag: %v", err)
 	}
-	
-	// Handle --no-cache flag (check parent flags since it's persistent)
-	noCacheFlag, _ := cmd.Parent().Flags().GetBool("no-cache")
-	if noCacheFlag {
-		cacheEnabled = false
-	}
-	
-	fmt.Printf("🚂 Starting top transit route analysis...\n")
-	fmt.Printf("📍 URL: %s\n", url)
-	fmt.Printf("💾 Cache: %t\n", cacheEnabled)
-	fmt.Printf("📊 Limit: %d routes\n", limit)
-	if maxDistance > 0 {
-		fmt.Printf("📏 Max Distance: %d km\n", maxDistance)
-	}
-	fmt.Println()
-	
-	// Initialize cache directory if caching is enabled
-	if cacheEnabled {
-		if err := cache.InitCache(); err != nil {
-			return fmt.Errorf("error initializing cache: %v", err)
-		}
+
+	// Validate output format
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
 	}
-	
-	// Fetch the webpage with or without caching
-	var htmlContent string
-	
-	if cacheEnabled {
-		htmlContent, err = client.FetchWithCache(url)
-	} else {
-		fmt.Printf("🌐 Fetching from network (cache disabled): %s\n", url)
-		htmlContent, err = client.FetchFromNetwork(url)
+
+	logging.Infof("🚂 Starting top transit route analysis...")
+	logging.Infof("📍 URL: %s", url)
+	logging.Infof("💾 Cache: %t", cacheEnabled)
+	logging.Infof("📊 Limit: %d routes", limit)
+	if maxDistance > 0 {
+		logging.Infof("📏 Max Distance: %d km", maxDistance)
 	}
-	
+	logging.Separator()
+
+	// Build the fetcher chain from the global flags
+	fetcher, err := newFetcher()
 	if err != nil {
-		return fmt.Errorf("error fetching URL: %v", err)
+		return err
 	}
-	
+
 	// Check if we need to fetch multiple pages
 	var allRoutes []types.TransitRoute
-	
+
 	if parser.ShouldFetchAllPages(url) {
-		fmt.Printf("🔄 Detecting multi-page transit data, fetching all pages...\n")
-		allRoutes, err = fetchAllTransitPages(url, cacheEnabled)
+		logging.Infof("🔄 Detecting multi-page transit data, fetching all pages...")
+		allRoutes, err = fetchAllTransitPages(url, fetcher, concurrency)
 		if err != nil {
 			return fmt.Errorf("error fetching all pages: %v", err)
 		}
 	} else {
-		// Single page - parse normally
-		allRoutes = parser.ParseTransitRoutes(htmlContent)
+		// Single page - fetch and parse normally
+		pages, err := fetchTransitPages(fetcher, []string{url}, 1)
+		if err != nil {
+			return fmt.Errorf("error fetching URL: %v", err)
+		}
+		allRoutes = pages[0].Routes
+		reportRowErrors("transit", pages[0].Diagnostics)
+		logging.Infof("📋 Transit table: %v", pages[0].Diagnostics)
 	}
-	
-	fmt.Printf("Found %d transit routes total\n", len(allRoutes))
-	
+
+	logging.Infof("Found %d transit routes total", len(allRoutes))
+
+	// Filter, sort and limit results
+	routes, sortedByDistance := rankTransitRoutes(allRoutes, limit, maxDistance, url)
+
 	// Display results
-	displayTransitRoutes(allRoutes, limit, maxDistance, url)
-	
+	if format != output.FormatText {
+		return output.WriteTransitRoutes(os.Stdout, format, routes)
+	}
+	displayTransitRoutes(routes, sortedByDistance, maxDistance)
+
 	return nil
 }
 
 // fetchAllTransitPages fetches all pages of transit data
-func fetchAllTransitPages(baseURL string, cacheEnabled bool) ([]types.TransitRoute, error) {
-	var allRoutes []types.TransitRoute
-	pageNum := 1
-	
+func fetchAllTransitPages(baseURL string, fetcher client.Fetcher, concurrency int) ([]types.TransitRoute, error) {
+	// The first page tells us how many pages its pagination links to
+	firstPageURL := parser.BuildPageURL(baseURL, 1)
+	logging.Infof("📄 Fetching page 1: %s", firstPageURL)
+	firstPage, err := fetchTransitPages(fetcher, []string{firstPageURL}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching page 1: %v", err)
+	}
+
+	pages := map[int]transitPage{1: firstPage[0]}
+	linkedPages := firstPage[0].LastPage
+	lastPage := min(linkedPages, maxTransitPages)
+
+	// Pagination may only link a window of pages, so keep going until no new pages show up
 	for {
-		// Construct page URL
-		separator := "?"
-		if strings.Contains(baseURL, "?") {
-			separator = "&"
+		var pageNumbers []int
+		var pageURLs []string
+		for pageNum := 2; pageNum <= lastPage; pageNum++ {
+			if _, fetched := pages[pageNum]; !fetched {
+				pageNumbers = append(pageNumbers, pageNum)
+				pageURLs = append(pageURLs, parser.BuildPageURL(baseURL, pageNum))
+			}
 		}
-		pageURL := fmt.Sprintf("%s%spage=%d", baseURL, separator, pageNum)
-		
-		fmt.Printf("📄 Fetching page %d: %s\n", pageNum, pageURL)
-		
-		// Fetch page content
-		var htmlContent string
-		var err error
-		
-		if cacheEnabled {
-			htmlContent, err = client.FetchWithCache(pageURL)
-		} else {
-			fmt.Printf("🌐 Fetching from network (cache disabled): %s\n", pageURL)
-			htmlContent, err = client.FetchFromNetwork(pageURL)
+		if len(pageURLs) == 0 {
+			break
 		}
-		
+
+		logging.Infof("📄 Fetching pages %d-%d with %d workers", pageNumbers[0], pageNumbers[len(pageNumbers)-1], concurrency)
+		fetched, err := fetchTransitPages(fetcher, pageURLs, concurrency)
 		if err != nil {
-			return nil, fmt.Errorf("error fetching page %d: %v", pageNum, err)
+			return nil, err
 		}
-		
-		// Parse routes from this page
-		pageRoutes := parser.ParseTransitRoutes(htmlContent)
-		
-		// If no routes found on this page, we've reached the end
-		if len(pageRoutes) == 0 {
-			fmt.Printf("✅ Reached end of pages at page %d (no routes found)\n", pageNum)
-			break
+
+		for i, page := range fetched {
+			pages[pageNumbers[i]] = page
+			linkedPages = max(linkedPages, page.LastPage)
+			lastPage = max(lastPage, min(page.LastPage, maxTransitPages))
 		}
-		
-		// Add routes from this page
+	}
+	if linkedPages > maxTransitPages {
+		logging.Warnf("⚠️  Pagination links %d pages, fetched only the first %d", linkedPages, maxTransitPages)
+	}
+
+	// Collect routes in page order
+	var allRoutes []types.TransitRoute
+	var diagnostics parser.Diagnostics
+	for pageNum := 1; pageNum <= len(pages); pageNum++ {
+		pageRoutes := pages[pageNum].Routes
 		allRoutes = append(allRoutes, pageRoutes...)
-		fmt.Printf("   Found %d routes on page %d (total: %d)\n", len(pageRoutes), pageNum, len(allRoutes))
-		
-		// Check if there's a "Next" link or pagination indicator
-		// If this page has fewer routes than expected, it might be the last page
-		if len(pageRoutes) < 10 { // Assuming typical page size is around 10+ routes
-			fmt.Printf("✅ Likely reached last page (only %d routes found)\n", len(pageRoutes))
-			break
-		}
-		
-		pageNum++
-		
-		// Safety limit to prevent infinite loops
-		if pageNum > 20 { // Reasonable upper limit
-			fmt.Printf("⚠️  Reached safety limit of 20 pages\n")
-			break
-		}
+		diagnostics.Merge(pages[pageNum].Diagnostics)
+		logging.Infof("   Found %d routes on page %d (total: %d)", len(pageRoutes), pageNum, len(allRoutes))
+		reportRowErrors(fmt.Sprintf("transit page %d", pageNum), pages[pageNum].Diagnostics)
 	}
-	
-	fmt.Printf("🔄 Fetched %d pages with total %d routes\n", pageNum-1, len(allRoutes))
+
+	logging.Infof("🔄 Fetched %d pages with total %d routes", len(pages), len(allRoutes))
+	logging.Infof("📋 Transit table: %v", diagnostics)
 	return allRoutes, nil
 }
 
-// displayTransitRoutes displays and sorts transit routes
-func displayTransitRoutes(routes []types.TransitRoute, limit int, maxDistance int, originalURL string) {
-	fmt.Println("\n=== TOP TRANSIT ROUTES ===")
-	
-	if len(routes) == 0 {
-		fmt.Println("No transit routes found.")
-		return
-	}
-	
+// rankTransitRoutes filters, sorts and limits transit routes, reporting whether they are sorted by distance
+func rankTransitRoutes(routes []types.TransitRoute, limit int, maxDistance int, originalURL string) ([]types.TransitRoute, bool) {
 	// Filter by max distance if specified
 	if maxDistance > 0 {
 		var filteredRoutes []types.TransitRoute
@@ -172,20 +163,15 @@ func displayTransitRoutes(routes []types.TransitRoute, limit int, maxDistance in
 			}
 		}
 		routes = filteredRoutes
-		fmt.Printf("After distance filtering (≤%d km): %d routes\n", maxDistance, len(routes))
-		
-		if len(routes) == 0 {
-			fmt.Printf("No routes found within %d km distance limit.\n", maxDistance)
-			return
-		}
+		logging.Infof("After distance filtering (≤%d km): %d routes", maxDistance, len(routes))
 	}
-	
+
 	// Sort routes by distance (lowest first) when using multi-page fetching OR when max-distance filter is specified
 	// Otherwise sort by train count for single pages
 	shouldSortByDistance := parser.ShouldFetchAllPages(originalURL) || maxDistance > 0
-	
+
 	if shouldSortByDistance {
-		fmt.Printf("📊 Sorting by distance (shortest routes first)...\n")
+		logging.Infof("📊 Sorting by distance (shortest routes first)...")
 		// Sort by distance (ascending)
 		for i := 0; i < len(routes)-1; i++ {
 			for j := i + 1; j < len(routes); j++ {
@@ -197,7 +183,7 @@ func displayTransitRoutes(routes []types.TransitRoute, limit int, maxDistance in
 			}
 		}
 	} else {
-		fmt.Printf("📊 Sorting by train availability (most trains first)...\n")
+		logging.Infof("📊 Sorting by train availability (most trains first)...")
 		// Sort routes by total train count (source + transit) in descending order
 		// This prioritizes routes with more train options
 		for i := 0; i < len(routes)-1; i++ {
@@ -210,36 +196,46 @@ func displayTransitRoutes(routes []types.TransitRoute, limit int, maxDistance in
 			}
 		}
 	}
-	
+
 	// Limit results
-	displayCount := len(routes)
 	if limit > 0 && limit < len(routes) {
-		displayCount = limit
 		routes = routes[:limit]
 	}
-	
-	if shouldSortByDistance {
-		fmt.Printf("Showing top %d routes (sorted by shortest distance):\n\n", displayCount)
+
+	return routes, shouldSortByDistance
+}
+
+// displayTransitRoutes displays ranked transit routes
+func displayTransitRoutes(routes []types.TransitRoute, sortedByDistance bool, maxDistance int) {
+	fmt.Println("\n=== TOP TRANSIT ROUTES ===")
+
+	if len(routes) == 0 {
+		if maxDistance > 0 {
+			fmt.Printf("No routes found within %d km distance limit.\n", maxDistance)
+		} else {
+			fmt.Println("No transit routes found.")
+		}
+		return
+	}
+
+	if sortedByDistance {
+		fmt.Printf("Showing top %d routes (sorted by shortest distance):\n\n", len(routes))
 	} else {
-		fmt.Printf("Showing top %d routes (sorted by total train availability):\n\n", displayCount)
+		fmt.Printf("Showing top %d routes (sorted by total train availability):\n\n", len(routes))
 	}
-	
+
 	for i, route := range routes {
 		totalTrains := route.SourceTrainCount + route.TransitTrainCount
-		
+
 		fmt.Printf("%d. %s (%s) → %s (%s) → %s (%s)\n",
 			i+1,
 			route.SourceStation, route.SourceStationCode,
 			route.TransitStation, route.TransitStationCode,
 			route.DestStation, route.DestStationCode)
-		
+
 		fmt.Printf("   🚂 Trains: %d + %d = %d total | 📏 Distance: %s\n",
 			route.SourceTrainCount, route.TransitTrainCount, totalTrains, route.Distance)
-		
-		fmt.Printf("   🔗 Details: https://etrain.info%s\n\n", route.ShowLink)
-	}
-	
-	if len(routes) > displayCount {
-		fmt.Printf("... and %d more routes available.\n", len(routes)-displayCount)
+
+		fmt.Printf("   🔗 Details: %s%s\n\n", parser.EtrainBaseURL, route.ShowLink)
 	}
-}
\ No newline at end of file
+}
@@ -2,95 +2,376 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
-	"trains/internal/cache"
 	"trains/internal/client"
+	"trains/internal/logging"
+	"trains/internal/output"
 	"trains/internal/parser"
 	"trains/internal/types"
 )
 
+// scheduleLayout formats the absolute times of connections found by date, e.g. "Tue 3 Nov 14:20"
+const scheduleLayout = "Mon 2 Jan 15:04"
+
+// viaRoute is one source → transit → destination route analyzed by viasearch
+type viaRoute struct {
+	URL                string
+	SourceStation      string
+	DestinationStation string
+	TransitStation     string
+}
+
 // runViaSearch handles the viasearch command
 func runViaSearch(cmd *cobra.Command, args []string) error {
-	// Get URL flag
-	url, err := cmd.Flags().GetString("url")
+	// Get day or date filter
+	travel, err := getTravelDay(cmd)
 	if err != nil {
-		return fmt.Errorf("error getting url flag: %v", err)
+		return err
 	}
-	
-	// Get day filter flag
-	dayFilter, err := cmd.Flags().GetString("day")
+
+	// Get layover and journey constraints
+	constraints, err := getConstraints(cmd)
 	if err != nil {
-		return fmt.Errorf("error getting day flag: %v", err)
+		return err
 	}
-	
-	// Validate and normalize day filter
-	if dayFilter != "" {
-		dayFilter, err = parser.ValidateAndNormalizeDay(dayFilter)
+
+	// Get sort, limit and pareto flags; a single route search lists same-day connections first
+	ranking, err := getRankingOptions(cmd)
+	if err != nil {
+		return err
+	}
+	ranking.GroupSameDay = true
+
+	// Validate output format
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
+	// Build the fetcher chain from the global flags
+	fetcher, err := newFetcher()
+	if err != nil {
+		return err
+	}
+
+	// Resolve routes from --url or --from/--to/--via
+	routes, err := resolveViaSearchRoutes(cmd, fetcher)
+	if err != nil {
+		return err
+	}
+
+	logging.Infof("🚂 Starting train route analysis...")
+	for _, route := range routes {
+		logging.Infof("📍 URL: %s", route.URL)
+	}
+	logging.Infof("💾 Cache: %t", cacheEnabled)
+	if travel.Day != "" {
+		logging.Infof("📅 Day Filter: %s", travel)
+	}
+	logging.Infof("⏱️  Layover: %s-%s | Max Journey: %s", parser.FormatMinutes(constraints.MinLayoverMinutes),
+		parser.FormatMinutes(constraints.MaxLayoverMinutes), parser.FormatMinutes(constraints.MaxJourneyMinutes))
+	logging.Separator()
+
+	// Fetch and analyze every route
+	connections, err := searchViaRoutes(fetcher, routes, travel.Day, constraints)
+	if err != nil {
+		return err
+	}
+	if !travel.Date.IsZero() {
+		scheduleConnections(connections, travel.Date)
+	}
+
+	// Filter, rank and limit the merged list
+	connections, found := rankConnections(connections, ranking)
+
+	// Generate results
+	if format != output.FormatText {
+		return output.WriteConnections(os.Stdout, format, connections)
+	}
+	transitStations := make([]string, 0, len(routes))
+	for _, route := range routes {
+		transitStations = append(transitStations, route.TransitStation)
+	}
+	generateConnections(connections, found, travel.String(), constraints, routes[0].SourceStation, routes[0].DestinationStation, strings.Join(transitStations, ", "))
+
+	return nil
+}
+
+// travelDay is the departure day from the source station given with --day or --date
+type travelDay struct {
+	Day  string    // Full day name, empty for any day
+	Date time.Time // Midnight of the travel date in IST, zero unless --date is given
+}
+
+// String describes the travel day, e.g. "Tuesday" or "Tuesday 3 Nov 2026"
+func (d travelDay) String() string {
+	if d.Date.IsZero() {
+		return d.Day
+	}
+	return d.Date.Format("Monday 2 Jan 2006")
+}
+
+// getTravelDay reads the day and date flags from the command flags
+func getTravelDay(cmd *cobra.Command) (travelDay, error) {
+	var travel travelDay
+
+	day, err := cmd.Flags().GetString("day")
+	if err != nil {
+		return travel, fmt.Errorf("error getting day flag: %v", err)
+	}
+	if day != "" {
+		travel.Day, err = parser.ValidateAndNormalizeDay(day)
+		return travel, err
+	}
+
+	date, err := cmd.Flags().GetString("date")
+	if err != nil {
+		return travel, fmt.Errorf("error getting date flag: %v", err)
+	}
+	if date == "" {
+		return travel, nil
+	}
+
+	now := time.Now()
+	if travel.Date, err = parser.ParseTravelDate(date, now); err != nil {
+		return travel, err
+	}
+	travel.Day = travel.Date.Weekday().String()
+
+	if today, _ := parser.ParseTravelDate("today", now); travel.Date.Before(today) {
+		logging.Warnf("⚠️  Travel date %s is in the past", travel)
+	}
+	return travel, nil
+}
+
+// rankingOptions controls how the merged connections are filtered, sorted and limited
+type rankingOptions struct {
+	SortBy       sortKey
+	Limit        int  // 0 = no limit
+	Pareto       bool // Keep only Pareto-optimal connections
+	GroupSameDay bool // List same-day connections before next-day ones, whatever SortBy
+}
+
+// getRankingOptions reads the sort, limit and pareto flags from the command flags
+func getRankingOptions(cmd *cobra.Command) (rankingOptions, error) {
+	var options rankingOptions
+
+	sortName, err := cmd.Flags().GetString("sort")
+	if err != nil {
+		return options, fmt.Errorf("error getting sort flag: %v", err)
+	}
+	options.SortBy, err = parseSortKey(sortName)
+	if err != nil {
+		return options, err
+	}
+
+	options.Limit, err = cmd.Flags().GetInt("limit")
+	if err != nil {
+		return options, fmt.Errorf("error getting limit flag: %v", err)
+	}
+	if options.Limit < 0 {
+		return options, fmt.Errorf("invalid limit %d: must not be negative", options.Limit)
+	}
+
+	options.Pareto, err = cmd.Flags().GetBool("pareto")
+	if err != nil {
+		return options, fmt.Errorf("error getting pareto flag: %v", err)
+	}
+
+	return options, nil
+}
+
+// rankConnections applies the ranking options, returning the connections to show and how many were found
+func rankConnections(connections []types.RouteConnection, options rankingOptions) ([]types.RouteConnection, int) {
+	if options.Pareto {
+		total := len(connections)
+		connections = paretoFront(connections)
+		logging.Infof("🎯 Pareto filter kept %d of %d connections", len(connections), total)
+	}
+	found := len(connections)
+
+	if options.GroupSameDay {
+		logging.Infof("📊 Sorting by %s (same-day connections first)...", options.SortBy)
+	} else {
+		logging.Infof("📊 Sorting by %s...", options.SortBy)
+	}
+	sortConnections(connections, options.SortBy, options.GroupSameDay)
+
+	if options.Limit > 0 && options.Limit < len(connections) {
+		connections = connections[:options.Limit]
+	}
+	return connections, found
+}
+
+// getConstraints reads the layover and journey limits from the command flags
+func getConstraints(cmd *cobra.Command) (parser.Constraints, error) {
+	constraints := parser.DefaultConstraints()
+
+	limits := []struct {
+		flag    string
+		minutes *int
+	}{
+		{"min-layover", &constraints.MinLayoverMinutes},
+		{"max-layover", &constraints.MaxLayoverMinutes},
+		{"max-journey", &constraints.MaxJourneyMinutes},
+	}
+	for _, limit := range limits {
+		duration, err := cmd.Flags().GetDuration(limit.flag)
 		if err != nil {
-			return err
+			return constraints, fmt.Errorf("error getting %s flag: %v", limit.flag, err)
 		}
+		*limit.minutes = int(duration.Minutes())
 	}
-	
-	// Handle --no-cache flag (check parent flags since it's persistent)
-	noCacheFlag, _ := cmd.Parent().Flags().GetBool("no-cache")
-	if noCacheFlag {
-		cacheEnabled = false
+
+	if err := constraints.Validate(); err != nil {
+		return constraints, err
 	}
-	
-	fmt.Printf("🚂 Starting train route analysis...\n")
-	fmt.Printf("📍 URL: %s\n", url)
-	fmt.Printf("💾 Cache: %t\n", cacheEnabled)
-	if dayFilter != "" {
-		fmt.Printf("📅 Day Filter: %s\n", dayFilter)
-	}
-	fmt.Println()
-	
-	// Initialize cache directory if caching is enabled
-	if cacheEnabled {
-		if err := cache.InitCache(); err != nil {
-			return fmt.Errorf("error initializing cache: %v", err)
-		}
-	}
-	
-	// Fetch the webpage with or without caching
-	var htmlContent string
-	
-	if cacheEnabled {
-		htmlContent, err = client.FetchWithCache(url)
+	return constraints, nil
+}
+
+// resolveViaSearchRoutes returns the routes to analyze for a viasearch run
+func resolveViaSearchRoutes(cmd *cobra.Command, fetcher client.Fetcher) ([]viaRoute, error) {
+	url, err := cmd.Flags().GetString("url")
+	if err != nil {
+		return nil, fmt.Errorf("error getting url flag: %v", err)
+	}
+
+	if url != "" {
+		// Extract route information from URL
+		sourceStation, destinationStation, transitStation, err := parser.ExtractRouteInfo(url)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing url flag: %v", err)
+		}
+		return []viaRoute{{URL: url, SourceStation: sourceStation, DestinationStation: destinationStation, TransitStation: transitStation}}, nil
+	}
+
+	from, _ := cmd.Flags().GetString("from")
+	to, _ := cmd.Flags().GetString("to")
+	via, _ := cmd.Flags().GetString("via")
+
+	// Stations may be given by name; the station directory turns them into codes
+	directory := loadStations()
+	endpoints, err := resolveStations(directory, []string{from, to})
+	if err != nil {
+		return nil, err
+	}
+	from, to = endpoints[0], endpoints[1]
+
+	var transitStations []string
+	if strings.EqualFold(strings.TrimSpace(via), "auto") {
+		viaTop, err := cmd.Flags().GetInt("via-top")
+		if err != nil {
+			return nil, fmt.Errorf("error getting via-top flag: %v", err)
+		}
+		transitStations, err = discoverTransitStations(fetcher, from, to, viaTop)
+		if err != nil {
+			return nil, err
+		}
 	} else {
-		fmt.Printf("🌐 Fetching from network (cache disabled): %s\n", url)
-		htmlContent, err = client.FetchFromNetwork(url)
-	}
-	
-	if err != nil {
-		return fmt.Errorf("error fetching URL: %v", err)
-	}
-	
-	// Parse train data from JavaScript objects in HTML
-	trains := parser.ParseTrainData(htmlContent)
-	
-	fmt.Printf("Found %d trains\n", len(trains))
-	
-	// Extract route information from URL
-	sourceStation, destinationStation, transitStation := parser.ExtractRouteInfo(url)
-	
-	// Group trains by source-destination pairs
-	connections := analyzeConnections(trains, dayFilter, sourceStation, destinationStation, transitStation)
-	
-	// Generate results
-	generateConnections(connections, dayFilter, sourceStation, destinationStation, transitStation)
-	
-	return nil
+		transitStations, err = resolveStations(directory, strings.Split(via, ","))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	routes := make([]viaRoute, 0, len(transitStations))
+	seen := make(map[string]bool, len(transitStations))
+	for _, transitStation := range transitStations {
+		url, err := parser.BuildViaSearchURL(from, to, transitStation)
+		if err != nil {
+			return nil, fmt.Errorf("error building viasearch URL: %v", err)
+		}
+
+		// Parse the built URL back so codes are normalized the same way in both modes
+		sourceStation, destinationStation, transitStation, err := parser.ExtractRouteInfo(url)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing built URL: %v", err)
+		}
+		if seen[transitStation] {
+			continue
+		}
+		seen[transitStation] = true
+
+		routes = append(routes, viaRoute{URL: url, SourceStation: sourceStation, DestinationStation: destinationStation, TransitStation: transitStation})
+	}
+
+	return routes, nil
+}
+
+// discoverTransitStations returns the codes of the top transit stations between two stations
+func discoverTransitStations(fetcher client.Fetcher, from, to string, top int) ([]string, error) {
+	transitRoutes, err := discoverTransitRoutes(fetcher, from, to, top)
+	if err != nil {
+		return nil, err
+	}
+
+	transitStations := make([]string, 0, len(transitRoutes))
+	for _, route := range transitRoutes {
+		transitStations = append(transitStations, route.TransitStationCode)
+	}
+	logging.Infof("🔎 Transit stations: %s", strings.Join(transitStations, ", "))
+
+	return transitStations, nil
+}
+
+// discoverTransitRoutes returns the top transit routes between two stations
+func discoverTransitRoutes(fetcher client.Fetcher, from, to string, top int) ([]types.TransitRoute, error) {
+	transitURL, err := parser.BuildTransitURL(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error building transit URL: %v", err)
+	}
+
+	logging.Infof("🔎 Discovering top %d transit routes from %s", top, transitURL)
+	transitRoutes, err := fetchAllTransitPages(transitURL, fetcher, concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching transit routes: %v", err)
+	}
+
+	// Same ranking as topsearch in multi-page mode (shortest distance first)
+	transitRoutes, _ = rankTransitRoutes(transitRoutes, top, 0, transitURL)
+	if len(transitRoutes) == 0 {
+		return nil, fmt.Errorf("no transit routes found between %s and %s", from, to)
+	}
+
+	return transitRoutes, nil
+}
+
+// searchViaRoutes fetches every route in parallel and merges their connections
+func searchViaRoutes(fetcher client.Fetcher, routes []viaRoute, dayFilter string, constraints parser.Constraints) ([]types.RouteConnection, error) {
+	urls := make([]string, 0, len(routes))
+	for _, route := range routes {
+		urls = append(urls, route.URL)
+	}
+
+	// Train data is parsed from data-train attributes in the HTML, or taken from the parse cache
+	pages, err := fetchTrainPages(fetcher, urls, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	var connections []types.RouteConnection
+	for i, route := range routes {
+		trains := pages[i].Trains
+		slog.Debug("📋 Train data", "via", route.TransitStation, "diagnostics", pages[i].Diagnostics.String())
+		logging.Infof("Found %d trains via %s", len(trains), route.TransitStation)
+
+		connections = append(connections, analyzeConnections(trains, dayFilter, constraints, route.SourceStation, route.DestinationStation, route.TransitStation)...)
+	}
+
+	return connections, nil
 }
 
 // separateTrainsByRoute separates trains into source-to-transit and transit-to-destination segments
 func separateTrainsByRoute(trains []types.TrainData, sourceStation, transitStation, destinationStation string) ([]types.TrainData, []types.TrainData) {
 	sourceToTransit := make([]types.TrainData, 0, len(trains)/2)
 	transitToDestination := make([]types.TrainData, 0, len(trains)/2)
-	
+
 	for _, train := range trains {
 		if train.SourceStationCode == sourceStation && train.DestStationCode == transitStation {
 			sourceToTransit = append(sourceToTransit, train)
@@ -98,145 +379,245 @@ func separateTrainsByRoute(trains []types.TrainData, sourceStation, transitStati
 			transitToDestination = append(transitToDestination, train)
 		}
 	}
-	
+
 	return sourceToTransit, transitToDestination
 }
 
 // isValidConnection checks if a connection is valid (not a "No Connection" type)
 func isValidConnection(connection types.RouteConnection) bool {
-	return connection.Connection != "No Connection - No common running days" && 
-		   connection.Connection != "No Connection - Insufficient layover time" &&
-		   connection.Connection != "No Connection - Layover too long (>4h)"
+	return connection.Rejection == types.NotRejected
+}
+
+// describeRejection explains a rejection using the thresholds it was checked against
+func describeRejection(rejection types.Rejection, constraints parser.Constraints) string {
+	switch rejection {
+	case types.RejectedLayoverTooShort:
+		return fmt.Sprintf("No Connection - %s (<%s)", rejection, parser.FormatMinutes(constraints.MinLayoverMinutes))
+	case types.RejectedLayoverTooLong:
+		return fmt.Sprintf("No Connection - %s (>%s)", rejection, parser.FormatMinutes(constraints.MaxLayoverMinutes))
+	case types.RejectedJourneyTooLong:
+		return fmt.Sprintf("No Connection - %s (≥%s)", rejection, parser.FormatMinutes(constraints.MaxJourneyMinutes))
+	}
+	return fmt.Sprintf("No Connection - %s", rejection)
 }
 
 // analyzeConnections finds valid train connections
-func analyzeConnections(trains []types.TrainData, dayFilter string, sourceStation string, destinationStation string, transitStation string) []types.RouteConnection {
+func analyzeConnections(trains []types.TrainData, dayFilter string, constraints parser.Constraints, sourceStation string, destinationStation string, transitStation string) []types.RouteConnection {
 	connections := make([]types.RouteConnection, 0, 10) // Estimate initial capacity
-	
+
 	// Separate trains by route segments
 	sourceToTransit, transitToDestination := separateTrainsByRoute(trains, sourceStation, transitStation, destinationStation)
-	
-	fmt.Printf("%s to %s trains: %d\n", sourceStation, transitStation, len(sourceToTransit))
-	fmt.Printf("%s to %s trains: %d\n", transitStation, destinationStation, len(transitToDestination))
-	
+
+	logging.Infof("%s to %s trains: %d", sourceStation, transitStation, len(sourceToTransit))
+	logging.Infof("%s to %s trains: %d", transitStation, destinationStation, len(transitToDestination))
+
 	// Find valid connections
 	for _, train1 := range sourceToTransit {
 		for _, train2 := range transitToDestination {
-			connection := analyzeConnection(train1, train2)
+			connection := analyzeConnection(train1, train2, constraints)
 			if !isValidConnection(connection) {
 				continue
 			}
-			
+
 			// Apply day filter if specified
 			if dayFilter != "" && !connectionMatchesDay(connection, dayFilter) {
 				continue
 			}
-			
+
 			connections = append(connections, connection)
 		}
 	}
-	
+
 	return connections
 }
 
+// legMinutes returns how long a train takes between its two stations, using TravelTime when available
+func legMinutes(train types.TrainData) int {
+	if minutes, ok := parser.ParseTravelTime(train.TravelTime); ok {
+		return minutes
+	}
+
+	// Without a travel time assume the train arrives within 24 hours
+	minutes := parser.ParseTime(train.DestTime) - parser.ParseTime(train.SourceTime)
+	if minutes < 0 {
+		minutes += parser.MinutesPerDay
+	}
+	return minutes
+}
+
 // analyzeConnection analyzes connection between two trains
-func analyzeConnection(train1, train2 types.TrainData) types.RouteConnection {
+//
+// All times are minutes on an absolute timeline starting at midnight of the day train1
+// leaves the source station, so journeys spanning several days are handled correctly.
+func analyzeConnection(train1, train2 types.TrainData, constraints parser.Constraints) types.RouteConnection {
 	connection := types.RouteConnection{
 		Train1: train1,
 		Train2: train2,
 	}
-	
-	// First check if trains have overlapping running days
-	commonDays := parser.GetCommonRunningDays(train1.RunningDays, train2.RunningDays)
-	if commonDays == "" {
-		connection.Connection = "No Connection - No common running days"
+
+	// reject records why the trains don't connect
+	reject := func(rejection types.Rejection) types.RouteConnection {
+		connection.Rejection = rejection
+		connection.Connection = describeRejection(rejection, constraints)
 		return connection
 	}
-	
-	// Parse times
-	time1 := parser.ParseTime(train1.DestTime) // Arrival at Kalyan
-	time2 := parser.ParseTime(train2.SourceTime) // Departure from Kalyan
-	
-	// Check if connection is possible (layover between 1-4 hours)
-	layoverMinutes := time2 - time1
-	if layoverMinutes >= parser.MinLayoverMinutes && layoverMinutes <= parser.MaxLayoverMinutes {
-		connection.Connection = fmt.Sprintf("Same day - %dh %dm layover (%s)", layoverMinutes/parser.MinutesPerHour, layoverMinutes%parser.MinutesPerHour, commonDays)
-		
-		// Calculate total journey time
-		startTime := parser.ParseTime(train1.SourceTime)
-		endTime := parser.ParseTime(train2.DestTime)
-		if endTime < startTime {
-			endTime += parser.MinutesPerDay // Next day
-		}
-		totalMinutes := endTime - startTime
-		connection.TotalTime = fmt.Sprintf("%dh %dm", totalMinutes/parser.MinutesPerHour, totalMinutes%parser.MinutesPerHour)
-	} else {
-		// Check next day connection (layover between 1-4 hours)
-		nextDayTime2 := time2 + parser.MinutesPerDay
-		layover := nextDayTime2 - time1
-		if layover >= parser.MinLayoverMinutes && layover <= parser.MaxLayoverMinutes {
-			connection.Connection = fmt.Sprintf("Next day - %dh %dm layover (%s)", layover/parser.MinutesPerHour, layover%parser.MinutesPerHour, commonDays)
-			
-			startTime := parser.ParseTime(train1.SourceTime)
-			endTime := parser.ParseTime(train2.DestTime) + parser.MinutesPerDay // Next day
-			totalMinutes := endTime - startTime
-			connection.TotalTime = fmt.Sprintf("%dh %dm", totalMinutes/parser.MinutesPerHour, totalMinutes%parser.MinutesPerHour)
-		} else {
-			// No valid connection
-			if layoverMinutes < parser.MinLayoverMinutes || layover < parser.MinLayoverMinutes {
-				connection.Connection = "No Connection - Insufficient layover time"
-			} else {
-				connection.Connection = "No Connection - Layover too long (>4h)"
-			}
+
+	// First check if trains run at all
+	days1 := types.ParseDayMask(train1.RunningDays)
+	days2 := types.ParseDayMask(train2.RunningDays)
+	if days1 == 0 || days2 == 0 {
+		return reject(types.RejectedNoCommonDays)
+	}
+
+	// Arrival at the transit station, possibly several days after departure
+	startTime := parser.ParseTime(train1.SourceTime)
+	arrivalTime := startTime + legMinutes(train1)
+	arrivalDay := arrivalTime / parser.MinutesPerDay
+
+	// Earliest departure of train2 that respects the minimum layover
+	departureTime := arrivalDay*parser.MinutesPerDay + parser.ParseTime(train2.SourceTime)
+	for departureTime-arrivalTime < constraints.MinLayoverMinutes {
+		departureTime += parser.MinutesPerDay
+	}
+
+	// The departure just before it is too soon; it decides between "too short" and "too long"
+	missedDeparture := departureTime - parser.MinutesPerDay
+	if departureTime-arrivalTime > constraints.MaxLayoverMinutes {
+		if missedDeparture >= arrivalTime {
+			return reject(types.RejectedLayoverTooShort)
+		}
+		return reject(types.RejectedLayoverTooLong)
+	}
+
+	// Every departure within the layover window on a day train2 actually runs serves some of
+	// train1's departure days. Train2 leaves departureDay days after train1 (the arrival-day
+	// offset plus the layover's day offset), so its running days rotate back by that many days
+	// to line up with train1's departure days from the source station. The earliest departure
+	// is the one reported; with windows of a day or more, later departures add the days only
+	// they serve, as long as the journey stays under the limit.
+	var commonDays types.DayMask
+	firstDeparture := -1
+	for ; departureTime-arrivalTime <= constraints.MaxLayoverMinutes; departureTime += parser.MinutesPerDay {
+		departureDay := departureTime / parser.MinutesPerDay
+		days := days1 & days2.Rotate(-departureDay)
+		if days == 0 {
+			continue
 		}
+		if firstDeparture < 0 {
+			firstDeparture = departureTime
+		} else if departureTime+legMinutes(train2)-startTime >= constraints.MaxJourneyMinutes {
+			break
+		}
+		commonDays |= days
+	}
+	if commonDays == 0 {
+		return reject(types.RejectedNoCommonDays)
+	}
+	departureTime = firstDeparture
+
+	departureDay := departureTime / parser.MinutesPerDay
+	layoverMinutes := departureTime - arrivalTime
+	connection.LayoverMinutes = layoverMinutes
+	connection.TransitArrivalDay = arrivalDay
+	connection.TransitDepartureDay = departureDay
+	connection.RunningDays = commonDays
+
+	layover := fmt.Sprintf("%dh %dm layover (%s)", layoverMinutes/parser.MinutesPerHour, layoverMinutes%parser.MinutesPerHour, commonDays)
+	switch departureDay - arrivalDay {
+	case 0:
+		connection.Connection = "Same day - " + layover
+	case 1:
+		connection.Connection = "Next day - " + layover
+	default:
+		connection.Connection = fmt.Sprintf("Day +%d - %s", departureDay-arrivalDay, layover)
+	}
+
+	// Calculate total journey time
+	endTime := departureTime + legMinutes(train2)
+	totalMinutes := endTime - startTime
+	connection.TotalMinutes = totalMinutes
+	connection.TotalTime = fmt.Sprintf("%dh %dm", totalMinutes/parser.MinutesPerHour, totalMinutes%parser.MinutesPerHour)
+
+	if totalMinutes >= constraints.MaxJourneyMinutes {
+		return reject(types.RejectedJourneyTooLong)
 	}
-	
+
 	return connection
 }
 
-// connectionMatchesDay checks if connection runs on specified day
+// scheduleConnections sets the absolute departure and arrival times of connections
+// leaving the source station on date. In layover windows of a day or more, train2 is the
+// first departure that runs on that date's connecting day, which may be later than the
+// departure the connection reports.
+func scheduleConnections(connections []types.RouteConnection, date time.Time) {
+	for i := range connections {
+		conn := &connections[i]
+		days2 := types.ParseDayMask(conn.Train2.RunningDays)
+		transitDay := conn.TransitDepartureDay
+		for offset := 0; offset < 7; offset++ {
+			if days2.Has(time.Weekday((int(date.Weekday()) + conn.TransitDepartureDay + offset) % 7)) {
+				transitDay += offset
+				break
+			}
+		}
+
+		departure := date.Add(time.Duration(parser.ParseTime(conn.Train1.SourceTime)) * time.Minute)
+		transitDeparture := date.AddDate(0, 0, transitDay).Add(time.Duration(parser.ParseTime(conn.Train2.SourceTime)) * time.Minute)
+		conn.Schedule = &types.Schedule{
+			Train1Departure: departure,
+			Train1Arrival:   departure.Add(time.Duration(legMinutes(conn.Train1)) * time.Minute),
+			Train2Departure: transitDeparture,
+			Train2Arrival:   transitDeparture.Add(time.Duration(legMinutes(conn.Train2)) * time.Minute),
+		}
+	}
+}
+
+// connectionMatchesDay checks if connection can be started on the specified day
 func connectionMatchesDay(connection types.RouteConnection, dayFilter string) bool {
-	// Check if the connection runs on the specified day
-	commonDays := parser.GetCommonRunningDays(connection.Train1.RunningDays, connection.Train2.RunningDays)
-	if commonDays == "" {
+	// The filter is the departure day from the source station, which is what RunningDays holds
+	day, ok := parser.ParseWeekday(dayFilter)
+	if !ok {
 		return false
 	}
-	
-	// Check if the dayFilter is in the common running days
-	dayAbbreviation := parser.GetDayAbbreviation(dayFilter)
-	return strings.Contains(commonDays, dayAbbreviation)
+	return connection.RunningDays.Has(day)
 }
 
-// generateConnections displays the connection results
-func generateConnections(connections []types.RouteConnection, dayFilter string, sourceStation string, destinationStation string, transitStation string) {
+// generateConnections displays the connection results; dayFilter describes the travel day or date, if any
+func generateConnections(validConnections []types.RouteConnection, found int, dayFilter string, constraints parser.Constraints, sourceStation string, destinationStation string, transitStation string) {
 	if dayFilter != "" {
 		fmt.Printf("\n=== TRAIN CONNECTIONS FROM %s TO %s VIA %s (Available on %s) ===\n\n", sourceStation, destinationStation, transitStation, dayFilter)
 	} else {
 		fmt.Printf("\n=== TRAIN CONNECTIONS FROM %s TO %s VIA %s ===\n\n", sourceStation, destinationStation, transitStation)
 	}
-	
-	// Filter connections under 19 hours
-	var validConnections []types.RouteConnection
-	for _, conn := range connections {
-		if parser.IsUnder19Hours(conn.TotalTime) {
-			validConnections = append(validConnections, conn)
-		}
-	}
-	
+
+	maxJourney := parser.FormatMinutes(constraints.MaxJourneyMinutes)
 	if dayFilter != "" {
-		fmt.Printf("Found %d connections under 19 hours available on %s:\n\n", len(validConnections), dayFilter)
+		fmt.Printf("Found %d connections under %s available on %s", found, maxJourney, dayFilter)
 	} else {
-		fmt.Printf("Found %d connections under 19 hours:\n\n", len(validConnections))
+		fmt.Printf("Found %d connections under %s", found, maxJourney)
+	}
+	if len(validConnections) < found {
+		fmt.Printf(", showing top %d", len(validConnections))
 	}
-	
+	fmt.Printf(":\n\n")
+
 	for i, conn := range validConnections {
-		fmt.Printf("%d. %s %s + %s %s\n", 
+		fmt.Printf("%d. %s %s + %s %s\n",
 			i+1, conn.Train1.Number, conn.Train1.Name, conn.Train2.Number, conn.Train2.Name)
-		fmt.Printf("   %s %s → %s %s → %s %s\n", 
-			sourceStation, conn.Train1.SourceTime, transitStation, conn.Train1.DestTime, destinationStation, conn.Train2.DestTime)
-		fmt.Printf("   Total Time: %s | Connection: %s\n", 
+		fmt.Printf("   %s %s → %s %s → %s %s\n",
+			conn.Train1.SourceStationCode, conn.Train1.SourceTime, conn.Train1.DestStationCode, conn.Train1.DestTime, conn.Train2.DestStationCode, conn.Train2.DestTime)
+		fmt.Printf("   Total Time: %s | Connection: %s\n",
 			conn.TotalTime, conn.Connection)
-		fmt.Printf("   Days: %s + %s\n\n", 
-			parser.FormatRunningDays(conn.Train1.RunningDays), parser.FormatRunningDays(conn.Train2.RunningDays))
+		fmt.Printf("   Days: %s + %s | Departs: %s\n",
+			parser.FormatRunningDays(conn.Train1.RunningDays), parser.FormatRunningDays(conn.Train2.RunningDays), parser.FormatDayMask(conn.RunningDays))
+		if schedule := conn.Schedule; schedule != nil {
+			fmt.Printf("   Schedule: %s %s → %s %s | %s %s → %s %s\n",
+				conn.Train1.SourceStationCode, schedule.Train1Departure.Format(scheduleLayout), conn.Train1.DestStationCode, schedule.Train1Arrival.Format(scheduleLayout),
+				conn.Train2.SourceStationCode, schedule.Train2Departure.Format(scheduleLayout), conn.Train2.DestStationCode, schedule.Train2Arrival.Format(scheduleLayout))
+		}
+		if len(conn.BestFor) > 0 {
+			fmt.Printf("   Best for: %s\n", describeBestFor(conn.BestFor))
+		}
+		fmt.Println()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"trains/internal/parser"
+	"trains/internal/types"
+)
+
+// testTrain builds a train for connection tests
+func testTrain(number, from, departure, to, arrival, travelTime, days string) types.TrainData {
+	return types.TrainData{
+		Number:            number,
+		SourceStationCode: from,
+		SourceTime:        departure,
+		DestStationCode:   to,
+		DestTime:          arrival,
+		TravelTime:        travelTime,
+		RunningDays:       days,
+	}
+}
+
+func TestAnalyzeConnection(t *testing.T) {
+	daily := "1111111"
+	tests := []struct {
+		name           string
+		train1         types.TrainData
+		train2         types.TrainData
+		constraints    parser.Constraints
+		rejection      types.Rejection
+		connection     string
+		layoverMinutes int
+		totalMinutes   int
+	}{
+		{
+			name:           "Same day connection",
+			train1:         testTrain("1", "BL", "01:08", "KYN", "04:42", "03:34", "0001000"),
+			train2:         testTrain("2", "KYN", "06:27", "NED", "18:00", "11:33", daily),
+			constraints:    parser.DefaultConstraints(),
+			connection:     "Same day - 1h 45m layover (Wed)",
+			layoverMinutes: 105,
+			totalMinutes:   1012,
+		},
+		{
+			name:           "Next day connection",
+			train1:         testTrain("1", "BL", "20:00", "KYN", "23:00", "03:00", daily),
+			train2:         testTrain("2", "KYN", "01:00", "NED", "09:00", "08:00", daily),
+			constraints:    parser.DefaultConstraints(),
+			connection:     "Next day - 2h 0m layover (Sun,Mon,Tue,Wed,Thu,Fri,Sat)",
+			layoverMinutes: 120,
+			totalMinutes:   780,
+		},
+		{
+			// Monday departures connect the same day, Tuesday ones wait until Wednesday
+			name:           "Two-day window serves two origin days",
+			train1:         testTrain("1", "BL", "10:00", "KYN", "12:00", "02:00", "0110000"),
+			train2:         testTrain("2", "KYN", "14:00", "NED", "18:00", "04:00", "0101000"),
+			constraints:    parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 48 * 60, MaxJourneyMinutes: 40 * 60},
+			connection:     "Same day - 2h 0m layover (Mon,Tue)",
+			layoverMinutes: 120,
+			totalMinutes:   480,
+		},
+		{
+			// The Tuesday departure would take 32 hours
+			name:           "Two-day window within the journey limit",
+			train1:         testTrain("1", "BL", "10:00", "KYN", "12:00", "02:00", "0110000"),
+			train2:         testTrain("2", "KYN", "14:00", "NED", "18:00", "04:00", "0101000"),
+			constraints:    parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 48 * 60, MaxJourneyMinutes: 19 * 60},
+			connection:     "Same day - 2h 0m layover (Mon)",
+			layoverMinutes: 120,
+			totalMinutes:   480,
+		},
+		{
+			name:        "No common running days",
+			train1:      testTrain("1", "BL", "01:00", "KYN", "04:00", "03:00", "1000000"),
+			train2:      testTrain("2", "KYN", "06:00", "NED", "12:00", "06:00", "0100000"),
+			constraints: parser.DefaultConstraints(),
+			rejection:   types.RejectedNoCommonDays,
+			connection:  "No Connection - No common running days",
+		},
+		{
+			name:        "Layover too short for default minimum",
+			train1:      testTrain("1", "BL", "01:00", "KYN", "04:00", "03:00", daily),
+			train2:      testTrain("2", "KYN", "04:30", "NED", "12:00", "07:30", daily),
+			constraints: parser.DefaultConstraints(),
+			rejection:   types.RejectedLayoverTooShort,
+			connection:  "No Connection - Insufficient layover time (<1h)",
+		},
+		{
+			name:           "Short layover allowed by custom minimum",
+			train1:         testTrain("1", "BL", "01:00", "KYN", "04:00", "03:00", daily),
+			train2:         testTrain("2", "KYN", "04:30", "NED", "12:00", "07:30", daily),
+			constraints:    parser.Constraints{MinLayoverMinutes: 20, MaxLayoverMinutes: 240, MaxJourneyMinutes: 19 * 60},
+			connection:     "Same day - 0h 30m layover (Sun,Mon,Tue,Wed,Thu,Fri,Sat)",
+			layoverMinutes: 30,
+			totalMinutes:   660,
+		},
+		{
+			name:        "Layover too long for custom maximum",
+			train1:      testTrain("1", "BL", "01:00", "KYN", "04:00", "03:00", daily),
+			train2:      testTrain("2", "KYN", "07:00", "NED", "12:00", "05:00", daily),
+			constraints: parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 150, MaxJourneyMinutes: 19 * 60},
+			rejection:   types.RejectedLayoverTooLong,
+			connection:  "No Connection - Layover too long (>2h 30m)",
+		},
+		{
+			name:        "Journey too long for custom maximum",
+			train1:      testTrain("1", "BL", "01:08", "KYN", "04:42", "03:34", daily),
+			train2:      testTrain("2", "KYN", "06:27", "NED", "18:00", "11:33", daily),
+			constraints: parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 240, MaxJourneyMinutes: 12 * 60},
+			rejection:   types.RejectedJourneyTooLong,
+			connection:  "No Connection - Journey too long (≥12h)",
+		},
+		{
+			name:           "First leg longer than a day",
+			train1:         testTrain("1", "BL", "22:00", "KYN", "01:00", "27:00", "0100000"),
+			train2:         testTrain("2", "KYN", "03:00", "NED", "08:00", "05:00", "0001000"),
+			constraints:    parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 240, MaxJourneyMinutes: 48 * 60},
+			connection:     "Same day - 2h 0m layover (Mon)",
+			layoverMinutes: 120,
+			totalMinutes:   34 * 60,
+		},
+		{
+			name:           "Multi-day wait for the next running day",
+			train1:         testTrain("1", "BL", "01:00", "KYN", "04:00", "03:00", "0100000"),
+			train2:         testTrain("2", "KYN", "06:00", "NED", "10:00", "04:00", "0001000"),
+			constraints:    parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 60 * 60, MaxJourneyMinutes: 72 * 60},
+			connection:     "Day +2 - 50h 0m layover (Mon)",
+			layoverMinutes: 50 * 60,
+			totalMinutes:   57 * 60,
+		},
+		{
+			name:        "Second train runs only on the wrong day",
+			train1:      testTrain("1", "BL", "22:00", "KYN", "01:00", "27:00", "0100000"),
+			train2:      testTrain("2", "KYN", "03:00", "NED", "08:00", "05:00", "0010000"),
+			constraints: parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 240, MaxJourneyMinutes: 48 * 60},
+			rejection:   types.RejectedNoCommonDays,
+			connection:  "No Connection - No common running days",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := analyzeConnection(tt.train1, tt.train2, tt.constraints)
+
+			if result.Rejection != tt.rejection {
+				t.Errorf("Rejection = %v, want %v", result.Rejection, tt.rejection)
+			}
+			if result.Connection != tt.connection {
+				t.Errorf("Connection = %q, want %q", result.Connection, tt.connection)
+			}
+			if tt.rejection != types.NotRejected {
+				return
+			}
+			if result.LayoverMinutes != tt.layoverMinutes || result.TotalMinutes != tt.totalMinutes {
+				t.Errorf("minutes = (%d, %d), want (%d, %d)",
+					result.LayoverMinutes, result.TotalMinutes, tt.layoverMinutes, tt.totalMinutes)
+			}
+		})
+	}
+}
+
+func TestConnectionMatchesDay(t *testing.T) {
+	// Leaves BL on Tuesday night, reaches KYN after midnight and continues on Wednesday
+	overnight := analyzeConnection(
+		testTrain("1", "BL", "22:00", "KYN", "01:30", "03:30", "0010000"),
+		testTrain("2", "KYN", "03:00", "NED", "09:00", "06:00", "0001000"),
+		parser.DefaultConstraints(),
+	)
+	if overnight.Rejection != types.NotRejected {
+		t.Fatalf("overnight connection rejected: %s", overnight.Connection)
+	}
+	if overnight.RunningDays != types.ParseDayMask("0010000") {
+		t.Errorf("RunningDays = %s, want Tue", overnight.RunningDays)
+	}
+
+	// Both trains run daily but train2 only on Sunday: departing Saturday night connects on Sunday
+	weekend := analyzeConnection(
+		testTrain("3", "BL", "23:00", "KYN", "00:30", "01:30", "1111111"),
+		testTrain("4", "KYN", "02:00", "NED", "06:00", "04:00", "1000000"),
+		parser.DefaultConstraints(),
+	)
+
+	tests := []struct {
+		name       string
+		connection types.RouteConnection
+		day        string
+		expected   bool
+	}{
+		{name: "Overnight on departure day", connection: overnight, day: "Tuesday", expected: true},
+		{name: "Overnight on second train's day", connection: overnight, day: "Wednesday", expected: false},
+		{name: "Week wraps to Sunday", connection: weekend, day: "Saturday", expected: true},
+		{name: "Sunday departure misses Sunday train", connection: weekend, day: "Sunday", expected: false},
+		{name: "Unknown day", connection: overnight, day: "Someday", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := connectionMatchesDay(tt.connection, tt.day)
+			if result != tt.expected {
+				t.Errorf("connectionMatchesDay(%s) = %t, want %t", tt.day, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScheduleConnections(t *testing.T) {
+	// Leaves BL on Tuesday night and continues from KYN after midnight
+	connections := []types.RouteConnection{analyzeConnection(
+		testTrain("1", "BL", "22:00", "KYN", "01:30", "03:30", "0010000"),
+		testTrain("2", "KYN", "03:00", "NED", "09:00", "06:00", "0001000"),
+		parser.DefaultConstraints(),
+	)}
+	date, err := parser.ParseTravelDate("2026-11-03", time.Now())
+	if err != nil {
+		t.Fatalf("ParseTravelDate failed: %v", err)
+	}
+
+	scheduleConnections(connections, date)
+
+	schedule := connections[0].Schedule
+	if schedule == nil {
+		t.Fatal("Schedule not set")
+	}
+	for name, got := range map[string]struct {
+		at   time.Time
+		want string
+	}{
+		"Train1Departure": {schedule.Train1Departure, "2026-11-03T22:00:00+05:30"},
+		"Train1Arrival":   {schedule.Train1Arrival, "2026-11-04T01:30:00+05:30"},
+		"Train2Departure": {schedule.Train2Departure, "2026-11-04T03:00:00+05:30"},
+		"Train2Arrival":   {schedule.Train2Arrival, "2026-11-04T09:00:00+05:30"},
+	} {
+		if formatted := got.at.Format(time.RFC3339); formatted != got.want {
+			t.Errorf("%s = %s, want %s", name, formatted, got.want)
+		}
+	}
+
+	// With a two-day window, Tuesday departures take the Wednesday train2 (see TestAnalyzeConnection)
+	connections = []types.RouteConnection{analyzeConnection(
+		testTrain("1", "BL", "10:00", "KYN", "12:00", "02:00", "0110000"),
+		testTrain("2", "KYN", "14:00", "NED", "18:00", "04:00", "0101000"),
+		parser.Constraints{MinLayoverMinutes: 60, MaxLayoverMinutes: 48 * 60, MaxJourneyMinutes: 40 * 60},
+	)}
+	scheduleConnections(connections, date)
+	if got := connections[0].Schedule.Train2Departure.Format(time.RFC3339); got != "2026-11-04T14:00:00+05:30" {
+		t.Errorf("Train2Departure on a Tuesday = %s, want Wednesday 14:00", got)
+	}
+	if got := connections[0].Schedule.Train2Arrival.Format(time.RFC3339); got != "2026-11-04T18:00:00+05:30" {
+		t.Errorf("Train2Arrival on a Tuesday = %s, want Wednesday 18:00", got)
+	}
+}
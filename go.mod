@@ -2,9 +2,15 @@ module trains
 
 go 1.21
 
-require github.com/spf13/cobra v1.9.1
+require (
+	github.com/spf13/cobra v1.9.1
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/net v0.35.0
+	golang.org/x/term v0.29.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sys v0.30.0 // indirect
 )
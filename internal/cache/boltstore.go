@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"trains/internal/types"
+)
+
+const (
+	boltFileName = "cache.db"
+
+	// boltLockTimeout bounds how long a run waits for another process holding the database
+	boltLockTimeout = 10 * time.Second
+)
+
+// boltMu serializes database access within the process, since bbolt locks per open file
+// and a second open of the same file would wait for the first to close
+var boltMu sync.Mutex
+
+// BoltStore keeps every entry in one bucket of a single bbolt database file, keyed by URL.
+// The database is opened per operation so concurrent runs take turns instead of one
+// run holding the file lock for its whole lifetime.
+type BoltStore struct {
+	Path   string
+	Bucket string
+}
+
+// Key implements Store
+func (s *BoltStore) Key(url string) string {
+	return url
+}
+
+// Load implements Store
+func (s *BoltStore) Load(url string) (types.CacheEntry, error) {
+	var data []byte
+	err := s.view(func(bucket *bolt.Bucket) error {
+		if value := bucket.Get([]byte(url)); value != nil {
+			data = append([]byte(nil), value...)
+		}
+		return nil
+	})
+	if err != nil {
+		return types.CacheEntry{}, err
+	}
+	if data == nil {
+		return types.CacheEntry{}, fmt.Errorf("no cache entry for %s: %w", url, ErrNotFound)
+	}
+
+	entry, err := decodeEntry(data)
+	if err != nil {
+		return entry, fmt.Errorf("failed to parse cache entry for %s: %w", url, err)
+	}
+	return entry, nil
+}
+
+// Save implements Store
+func (s *BoltStore) Save(url, content string) error {
+	data, err := encodeEntry(newEntry(url, content))
+	if err != nil {
+		return err
+	}
+	return s.update(func(bucket *bolt.Bucket) error {
+		return bucket.Put([]byte(url), data)
+	})
+}
+
+// Scan implements Store
+func (s *BoltStore) Scan(fn func(Record) error) error {
+	return s.view(func(bucket *bolt.Bucket) error {
+		return bucket.ForEach(func(key, value []byte) error {
+			record := Record{Key: string(key), Size: int64(len(value))}
+			record.Entry, record.Err = decodeEntry(value)
+			return fn(record)
+		})
+	})
+}
+
+// Remove implements Store
+func (s *BoltStore) Remove(key string) error {
+	return s.update(func(bucket *bolt.Bucket) error {
+		if bucket.Get([]byte(key)) == nil {
+			return fmt.Errorf("no cache entry for %s: %w", key, ErrNotFound)
+		}
+		return bucket.Delete([]byte(key))
+	})
+}
+
+// view runs fn in a read-only transaction; a missing database has no entries
+func (s *BoltStore) view(fn func(*bolt.Bucket) error) error {
+	if _, err := os.Stat(s.Path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return s.withDB(func(db *bolt.DB) error {
+		return db.View(func(tx *bolt.Tx) error {
+			bucket := tx.Bucket([]byte(s.Bucket))
+			if bucket == nil {
+				return nil
+			}
+			return fn(bucket)
+		})
+	})
+}
+
+// update runs fn in a read-write transaction
+func (s *BoltStore) update(fn func(*bolt.Bucket) error) error {
+	return s.withDB(func(db *bolt.DB) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			bucket, err := tx.CreateBucketIfNotExists([]byte(s.Bucket))
+			if err != nil {
+				return fmt.Errorf("failed to create cache bucket: %w", err)
+			}
+			return fn(bucket)
+		})
+	})
+}
+
+// withDB opens the database for the duration of fn
+func (s *BoltStore) withDB(fn func(*bolt.DB) error) error {
+	boltMu.Lock()
+	defer boltMu.Unlock()
+
+	db, err := bolt.Open(s.Path, 0644, &bolt.Options{Timeout: boltLockTimeout})
+	if err != nil {
+		return fmt.Errorf("failed to open cache database %s: %w", s.Path, err)
+	}
+	defer db.Close()
+
+	return fn(db)
+}
@@ -3,83 +3,238 @@ package cache
 import (
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"trains/internal/types"
 )
 
 const (
-	cacheDir    = "./cache"
-	cacheExpiry = 24 * time.Hour // Cache expires after 24 hours
+	// DefaultTTL is how long entries stay fresh unless a resource-specific TTL applies
+	DefaultTTL = 24 * time.Hour
+	
+	// DefaultTransitTTL is the expiry of transit listing pages, which rarely change
+	DefaultTransitTTL = 7 * 24 * time.Hour
+	
+	// DefaultTrainTTL is the expiry of train listing pages, whose timetables change seasonally
+	DefaultTrainTTL = 24 * time.Hour
+)
+
+// Resource is the kind of page a URL points to
+type Resource string
+
+const (
+	ResourceTransit Resource = "transit" // Transit route listings (/transit/...)
+	ResourceTrain   Resource = "train"   // Train listings between stations (/trains/...)
+	ResourceOther   Resource = "other"
 )
 
+// Settings configure where entries are stored and how long they stay fresh
+type Settings struct {
+	Dir        string
+	Backend    Backend
+	TTL        time.Duration // Expiry of pages that are neither transit nor train listings
+	TransitTTL time.Duration
+	TrainTTL   time.Duration
+}
+
+var (
+	// settings are the active cache settings, replaced by Configure
+	settings = DefaultSettings()
+	
+	// store holds the pages for the active settings
+	store = NewStore(settings)
+	
+	// parsedStore holds parse results for the active settings
+	parsedStore = newNamespacedStore(settings, parsedNamespace)
+)
+
+// DefaultSettings returns the settings used when none are configured
+func DefaultSettings() Settings {
+	return Settings{
+		Dir:        DefaultDir(),
+		Backend:    BackendFile,
+		TTL:        DefaultTTL,
+		TransitTTL: DefaultTransitTTL,
+		TrainTTL:   DefaultTrainTTL,
+	}
+}
+
+// DefaultDir returns the per-user cache directory, e.g. ~/.cache/trains on Linux
+func DefaultDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "trains")
+	}
+	// No home directory (e.g. in minimal containers), keep the cache out of the working tree
+	return filepath.Join(os.TempDir(), "trains-cache")
+}
+
+// Configure replaces the active cache settings
+func Configure(s Settings) error {
+	if s.Dir == "" {
+		return fmt.Errorf("cache directory must not be empty")
+	}
+	if s.TTL <= 0 || s.TransitTTL <= 0 || s.TrainTTL <= 0 {
+		return fmt.Errorf("cache TTLs must be positive")
+	}
+	if s.Backend == "" {
+		s.Backend = BackendFile
+	}
+	if _, err := ParseBackend(string(s.Backend)); err != nil {
+		return err
+	}
+	settings = s
+	store = NewStore(s)
+	parsedStore = newNamespacedStore(s, parsedNamespace)
+	return nil
+}
+
+// CurrentSettings returns the active cache settings
+func CurrentSettings() Settings {
+	return settings
+}
+
+// ActiveStore returns the store for the active settings
+func ActiveStore() Store {
+	return store
+}
+
+// ParsedStore returns the store of parse results for the active settings
+func ParsedStore() Store {
+	return parsedStore
+}
+
+// Dir returns the active cache directory
+func Dir() string {
+	return settings.Dir
+}
+
+// ResourceOf classifies a URL by the kind of page it points to
+func ResourceOf(rawURL string) Resource {
+	path := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		path = parsed.Path
+	}
+	switch {
+	case strings.HasPrefix(path, "/transit/"):
+		return ResourceTransit
+	case strings.HasPrefix(path, "/trains/"):
+		return ResourceTrain
+	}
+	return ResourceOther
+}
+
+// TTLFor returns how long the entry for URL stays fresh
+func TTLFor(url string) time.Duration {
+	switch ResourceOf(url) {
+	case ResourceTransit:
+		return settings.TransitTTL
+	case ResourceTrain:
+		return settings.TrainTTL
+	}
+	return settings.TTL
+}
+
 // initCache creates cache directory if it doesn't exist
 func InitCache() error {
-	return os.MkdirAll(cacheDir, 0755)
+	if err := os.MkdirAll(settings.Dir, 0755); err != nil {
+		return err
+	}
+	if files, ok := parsedStore.(FileStore); ok {
+		return os.MkdirAll(files.Dir, 0755)
+	}
+	return nil
 }
 
-// getCacheFilePath generates MD5-hashed cache file path for URL
-func getCacheFilePath(url string) string {
+// EntryFileName returns the MD5-hashed file name used to store the entry for URL
+func EntryFileName(url string) string {
 	hash := md5.Sum([]byte(url))
-	filename := fmt.Sprintf("%x.json", hash)
-	return filepath.Join(cacheDir, filename)
+	return fmt.Sprintf("%x.json", hash)
 }
 
-// LoadFromCache loads content from cache if available and not expired
-func LoadFromCache(url string) (string, bool) {
-	filePath := getCacheFilePath(url)
+// LoadEntry reads the plain JSON entry for URL from dir without checking its age; used for fixture bundles
+func LoadEntry(dir, url string) (types.CacheEntry, error) {
+	data, err := os.ReadFile(filepath.Join(dir, EntryFileName(url)))
+	if err != nil {
+		return types.CacheEntry{}, fmt.Errorf("failed to read cache file for %s: %w", url, err)
+	}
 	
-	// Check if cache file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return "", false
+	entry, err := decodeEntry(data)
+	if err != nil {
+		return entry, fmt.Errorf("failed to parse cache entry for %s: %w", url, err)
 	}
 	
-	// Read cache file
-	data, err := os.ReadFile(filePath)
+	return entry, nil
+}
+
+// SaveEntry writes the entry for URL into dir as readable plain JSON; used for fixture bundles
+func SaveEntry(dir, url, content string) error {
+	filePath := filepath.Join(dir, EntryFileName(url))
+	
+	data, err := json.MarshalIndent(newEntry(url, content), "", "  ")
 	if err != nil {
-		fmt.Printf("Error reading cache file: %v\n", err)
-		return "", false
+		return fmt.Errorf("failed to marshal cache entry for %s: %w", url, err)
+	}
+	
+	if err := WriteFileAtomic(filePath, data); err != nil {
+		return err
 	}
 	
-	// Parse cache entry
-	var entry types.CacheEntry
-	if err := json.Unmarshal(data, &entry); err != nil {
-		fmt.Printf("Error parsing cache entry: %v\n", err)
+	return nil
+}
+
+// LoadFromCache loads content from cache if available and not expired
+func LoadFromCache(url string) (string, bool) {
+	entry, found := loadActive(url)
+	if !found {
 		return "", false
 	}
 	
 	// Check if cache is expired
-	if time.Since(entry.Timestamp) > cacheExpiry {
-		fmt.Printf("Cache expired for %s\n", url)
+	if time.Since(entry.Timestamp) > TTLFor(url) {
+		slog.Debug("Cache expired", "url", url)
 		return "", false
 	}
 	
-	fmt.Printf("💾 Cache hit for %s (cached %v ago)\n", url, time.Since(entry.Timestamp).Round(time.Minute))
+	slog.Debug("💾 Cache hit", "url", url, "age", time.Since(entry.Timestamp).Round(time.Minute))
 	return entry.Content, true
 }
 
-// SaveToCache saves content to cache
-func SaveToCache(url, content string) error {
-	filePath := getCacheFilePath(url)
-	
-	entry := types.CacheEntry{
-		URL:       url,
-		Content:   content,
-		Timestamp: time.Now(),
+// LoadStale loads content from cache regardless of its age, returning how old it is
+func LoadStale(url string) (string, time.Duration, bool) {
+	entry, found := loadActive(url)
+	if !found {
+		return "", 0, false
 	}
 	
-	data, err := json.MarshalIndent(entry, "", "  ")
+	return entry.Content, time.Since(entry.Timestamp), true
+}
+
+// loadActive loads the entry for url from the active store, reporting unreadable entries
+func loadActive(url string) (types.CacheEntry, bool) {
+	entry, err := store.Load(url)
+	if errors.Is(err, ErrNotFound) {
+		return entry, false
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal cache entry for %s: %w", url, err)
+		slog.Warn("⚠️  Failed to load cache entry", "url", url, "error", err)
+		return entry, false
 	}
-	
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write cache file %s: %w", filePath, err)
+	return entry, true
+}
+
+// SaveToCache saves content to cache
+func SaveToCache(url, content string) error {
+	if err := store.Save(url, content); err != nil {
+		return err
 	}
 	
-	fmt.Printf("💾 Cached response for %s\n", url)
+	slog.Debug("💾 Cached response", "url", url)
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLFor(t *testing.T) {
+	previous := settings
+	t.Cleanup(func() { settings = previous })
+
+	if err := Configure(Settings{Dir: t.TempDir(), TTL: time.Hour, TransitTTL: 7 * 24 * time.Hour, TrainTTL: 12 * time.Hour}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+
+	tests := []struct {
+		url      string
+		resource Resource
+		ttl      time.Duration
+	}{
+		{"https://etrain.info/transit/BL-NED", ResourceTransit, 7 * 24 * time.Hour},
+		{"https://etrain.info/transit/BL-NED?page=3", ResourceTransit, 7 * 24 * time.Hour},
+		{"https://etrain.info/trains/BL-to-NED-via-KYN", ResourceTrain, 12 * time.Hour},
+		{"https://etrain.info/station/KYN", ResourceOther, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := ResourceOf(tt.url); got != tt.resource {
+			t.Errorf("ResourceOf(%s) = %s, want %s", tt.url, got, tt.resource)
+		}
+		if got := TTLFor(tt.url); got != tt.ttl {
+			t.Errorf("TTLFor(%s) = %v, want %v", tt.url, got, tt.ttl)
+		}
+	}
+}
+
+func TestConfigureRejectsInvalidSettings(t *testing.T) {
+	previous := settings
+	t.Cleanup(func() { settings = previous })
+
+	if err := Configure(Settings{Dir: "", TTL: time.Hour, TransitTTL: time.Hour, TrainTTL: time.Hour}); err == nil {
+		t.Error("Configure accepted an empty directory")
+	}
+	if err := Configure(Settings{Dir: t.TempDir(), TTL: 0, TransitTTL: time.Hour, TrainTTL: time.Hour}); err == nil {
+		t.Error("Configure accepted a zero TTL")
+	}
+}
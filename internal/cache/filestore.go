@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"trains/internal/types"
+)
+
+const (
+	// fileExtension is used by FileStore for compressed entries
+	fileExtension = ".json.gz"
+
+	// legacyExtension is used by plain JSON entries of older versions and fixture bundles
+	legacyExtension = ".json"
+
+	lockFileName = ".lock"
+)
+
+// FileStore keeps one gzip-compressed JSON file per entry in Dir.
+// Writes go to a temporary file that is renamed into place, so readers never see a partial
+// entry, and a lock file serializes writers across processes.
+type FileStore struct {
+	Dir string
+}
+
+// Key implements Store
+func (s FileStore) Key(url string) string {
+	return fmt.Sprintf("%x%s", md5.Sum([]byte(url)), fileExtension)
+}
+
+// Load implements Store
+func (s FileStore) Load(url string) (types.CacheEntry, error) {
+	if !s.exists() {
+		return types.CacheEntry{}, fmt.Errorf("no cache entry for %s: %w", url, ErrNotFound)
+	}
+
+	unlock, err := lockFile(filepath.Join(s.Dir, lockFileName), false)
+	if err != nil {
+		return types.CacheEntry{}, err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.Dir, s.Key(url)))
+	if errors.Is(err, os.ErrNotExist) {
+		// Fall back to an entry written by an older version
+		data, err = os.ReadFile(filepath.Join(s.Dir, EntryFileName(url)))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return types.CacheEntry{}, fmt.Errorf("no cache entry for %s: %w", url, ErrNotFound)
+	}
+	if err != nil {
+		return types.CacheEntry{}, fmt.Errorf("failed to read cache file for %s: %w", url, err)
+	}
+
+	entry, err := decodeEntry(data)
+	if err != nil {
+		return entry, fmt.Errorf("failed to parse cache entry for %s: %w", url, err)
+	}
+	return entry, nil
+}
+
+// Save implements Store
+func (s FileStore) Save(url, content string) error {
+	data, err := encodeEntry(newEntry(url, content))
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(filepath.Join(s.Dir, lockFileName), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := WriteFileAtomic(filepath.Join(s.Dir, s.Key(url)), data); err != nil {
+		return err
+	}
+
+	// The compressed entry supersedes any legacy one
+	if err := os.Remove(filepath.Join(s.Dir, EntryFileName(url))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove legacy cache file for %s: %w", url, err)
+	}
+	return nil
+}
+
+// Scan implements Store
+func (s FileStore) Scan(fn func(Record) error) error {
+	if !s.exists() {
+		return nil
+	}
+
+	unlock, err := lockFile(filepath.Join(s.Dir, lockFileName), false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var files []string
+	for _, pattern := range []string{"*" + fileExtension, "*" + legacyExtension} {
+		matches, err := filepath.Glob(filepath.Join(s.Dir, pattern))
+		if err != nil {
+			return fmt.Errorf("failed to list cache directory %s: %w", s.Dir, err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		record := Record{Key: filepath.Base(file)}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			record.Err = err
+		} else {
+			record.Size = int64(len(data))
+			record.Entry, record.Err = decodeEntry(data)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove implements Store
+func (s FileStore) Remove(key string) error {
+	if filepath.Base(key) != key || !strings.HasSuffix(key, legacyExtension) && !strings.HasSuffix(key, fileExtension) {
+		return fmt.Errorf("invalid cache key %s", key)
+	}
+
+	unlock, err := lockFile(filepath.Join(s.Dir, lockFileName), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(filepath.Join(s.Dir, key)); err != nil {
+		return fmt.Errorf("failed to remove cache file %s: %w", key, err)
+	}
+	return nil
+}
+
+// exists reports whether the cache directory has been created
+func (s FileStore) exists() bool {
+	_, err := os.Stat(s.Dir)
+	return err == nil
+}
+
+// WriteFileAtomic writes data to a temporary file in the target directory and renames it into
+// place, so readers never see a partly written file
+func WriteFileAtomic(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary cache file: %w", err)
+	}
+	// Harmless once the rename succeeded
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write cache file %s: %w", path, err)
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to sync cache file %s: %w", path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to close cache file %s: %w", path, err)
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set permissions of cache file %s: %w", path, err)
+	}
+
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move cache file into place %s: %w", path, err)
+	}
+	return nil
+}
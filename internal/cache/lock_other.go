@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package cache
+
+// lockFile is a no-op where flock is unavailable; atomic renames still keep entries intact,
+// but concurrent writers of the same entry may overwrite each other
+func lockFile(path string, exclusive bool) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EntryStatus describes whether a cached entry would be served
+type EntryStatus string
+
+const (
+	StatusFresh   EntryStatus = "fresh"   // Served as a cache hit
+	StatusExpired EntryStatus = "expired" // Refetched on next use
+	StatusCorrupt EntryStatus = "corrupt" // Unreadable, ignored on load
+)
+
+// EntryInfo describes one stored cache entry
+type EntryInfo struct {
+	Key       string // Storage key, e.g. the file name
+	URL       string
+	Timestamp time.Time
+	Size      int64 // Stored size in bytes
+	Status    EntryStatus
+	Err       error // Why the entry is corrupt
+}
+
+// Age returns how long ago the entry was cached
+func (e EntryInfo) Age() time.Duration {
+	return time.Since(e.Timestamp)
+}
+
+// Stats summarizes the entries of a cache
+type Stats struct {
+	Entries    int
+	Fresh      int
+	Expired    int
+	Corrupt    int
+	TotalBytes int64
+	Oldest     time.Time
+	Newest     time.Time
+}
+
+// PurgeOptions selects the entries removed by Purge; entries must match every set option
+type PurgeOptions struct {
+	OlderThan  time.Duration  // Only entries cached longer ago than this, 0 = any age
+	URLPattern *regexp.Regexp // Only entries whose page URL matches, nil = any URL
+}
+
+// List describes every entry in store, oldest first
+func List(store Store) ([]EntryInfo, error) {
+	var entries []EntryInfo
+	err := store.Scan(func(record Record) error {
+		entries = append(entries, inspect(store, record))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
+
+// inspect checks that a stored record is a valid entry
+func inspect(store Store, record Record) EntryInfo {
+	entry := record.Entry
+	info := EntryInfo{
+		Key:       record.Key,
+		URL:       entry.URL,
+		Timestamp: entry.Timestamp,
+		Size:      record.Size,
+		Status:    StatusCorrupt,
+		Err:       record.Err,
+	}
+	if record.Err != nil {
+		return info
+	}
+
+	switch {
+	case entry.URL == "":
+		info.Err = errors.New("entry has no URL")
+	case record.Key != store.Key(entry.URL) && record.Key != EntryFileName(entry.URL):
+		info.Err = fmt.Errorf("key does not match URL (want %s)", store.Key(entry.URL))
+	case entry.Timestamp.IsZero():
+		info.Err = errors.New("entry has no timestamp")
+	case entry.Content == "":
+		info.Err = errors.New("entry has no content")
+	case time.Since(entry.Timestamp) > TTLFor(entry.URL):
+		info.Status = StatusExpired
+	default:
+		info.Status = StatusFresh
+	}
+	return info
+}
+
+// Summarize computes statistics over entries
+func Summarize(entries []EntryInfo) Stats {
+	var stats Stats
+	for _, entry := range entries {
+		stats.Entries++
+		stats.TotalBytes += entry.Size
+
+		switch entry.Status {
+		case StatusFresh:
+			stats.Fresh++
+		case StatusExpired:
+			stats.Expired++
+		case StatusCorrupt:
+			stats.Corrupt++
+			continue
+		}
+
+		if stats.Oldest.IsZero() || entry.Timestamp.Before(stats.Oldest) {
+			stats.Oldest = entry.Timestamp
+		}
+		if entry.Timestamp.After(stats.Newest) {
+			stats.Newest = entry.Timestamp
+		}
+	}
+	return stats
+}
+
+// Find returns the entry for URL in store
+func Find(store Store, url string) (EntryInfo, error) {
+	var info EntryInfo
+	found := false
+	key := store.Key(url)
+	err := store.Scan(func(record Record) error {
+		if record.Key == key || record.Key == EntryFileName(url) {
+			info, found = inspect(store, record), true
+		}
+		return nil
+	})
+	if err != nil {
+		return info, fmt.Errorf("failed to read cache: %w", err)
+	}
+	if !found {
+		return info, fmt.Errorf("no cache entry for %s: %w", url, ErrNotFound)
+	}
+	return info, nil
+}
+
+// Purge removes the entries in store selected by options and returns them. Parse results are
+// matched by the URL of their page, so a pattern removes a page and its results together.
+func Purge(store Store, options PurgeOptions) ([]EntryInfo, error) {
+	entries, err := List(store)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []EntryInfo
+	for _, entry := range entries {
+		if options.OlderThan > 0 && entry.Age() <= options.OlderThan {
+			continue
+		}
+		if options.URLPattern != nil && !options.URLPattern.MatchString(pageURL(entry.URL)) {
+			continue
+		}
+
+		if err := store.Remove(entry.Key); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+	return removed, nil
+}
+
+// Verify returns the corrupt entries in store
+func Verify(store Store) ([]EntryInfo, error) {
+	entries, err := List(store)
+	if err != nil {
+		return nil, err
+	}
+
+	var corrupt []EntryInfo
+	for _, entry := range entries {
+		if entry.Status == StatusCorrupt {
+			corrupt = append(corrupt, entry)
+		}
+	}
+	return corrupt, nil
+}
+
+// ParseURLPattern compiles a purge URL pattern; plain text matches as a substring
+func ParseURLPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL pattern '%s': %w", pattern, err)
+	}
+	return re, nil
+}
+
+// ParseAge parses a duration such as "36h" or "7d"; days are 24 hours
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.ParseFloat(days, 64)
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid age '%s': use a duration such as 36h or 7d", value)
+		}
+		return time.Duration(count * float64(24*time.Hour)), nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid age '%s': use a duration such as 36h or 7d", value)
+	}
+	return age, nil
+}
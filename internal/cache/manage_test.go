@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"trains/internal/types"
+)
+
+// writeTestEntry stores an entry for url that was cached age ago
+func writeTestEntry(t *testing.T, dir, url string, age time.Duration) {
+	t.Helper()
+	data, err := json.Marshal(types.CacheEntry{URL: url, Content: "<html></html>", Timestamp: time.Now().Add(-age)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, EntryFileName(url)), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListAndSummarize(t *testing.T) {
+	dir := t.TempDir()
+	writeTestEntry(t, dir, "https://etrain.info/transit/BL-NED", time.Hour)
+	writeTestEntry(t, dir, "https://etrain.info/trains/BL-to-NED-via-KYN", 48*time.Hour)
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List(FileStore{Dir: dir})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+
+	stats := Summarize(entries)
+	if stats.Entries != 3 || stats.Fresh != 1 || stats.Expired != 1 || stats.Corrupt != 1 {
+		t.Errorf("Summarize = %+v, want 3 entries (1 fresh, 1 expired, 1 corrupt)", stats)
+	}
+	if stats.TotalBytes == 0 {
+		t.Error("Summarize reported no size")
+	}
+
+	corrupt, err := Verify(FileStore{Dir: dir})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(corrupt) != 1 || corrupt[0].Key != "broken.json" {
+		t.Errorf("Verify = %+v, want broken.json only", corrupt)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	dir := t.TempDir()
+	writeTestEntry(t, dir, "https://etrain.info/transit/BL-NED", time.Hour)
+	writeTestEntry(t, dir, "https://etrain.info/transit/BL-NED?page=2", 10*24*time.Hour)
+	writeTestEntry(t, dir, "https://etrain.info/trains/BL-to-NED-via-KYN", 10*24*time.Hour)
+
+	removed, err := Purge(FileStore{Dir: dir}, PurgeOptions{OlderThan: 7 * 24 * time.Hour, URLPattern: regexp.MustCompile("transit/")})
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0].URL != "https://etrain.info/transit/BL-NED?page=2" {
+		t.Errorf("Purge removed %+v, want only the old transit page", removed)
+	}
+
+	entries, err := List(FileStore{Dir: dir})
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("%d entries left after purge, want 2", len(entries))
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"36h", 36 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"week", 0, true},
+		{"-1d", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAge(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v, error %t", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"time"
+)
+
+// parsedSuffix matches the parser version fragment that parsedKey appends to a URL
+var parsedSuffix = regexp.MustCompile(`#parser-v[0-9]+$`)
+
+// parsedKey is the key parse results for url are stored under. The parser version goes into the
+// URL fragment, so results of an older parser are never read and the URL keeps its resource kind.
+func parsedKey(url string, version int) string {
+	return fmt.Sprintf("%s#parser-v%d", url, version)
+}
+
+// pageURL returns the URL of the page a stored key belongs to: the key itself for pages, and
+// the key without its parser version for parse results
+func pageURL(key string) string {
+	return parsedSuffix.ReplaceAllString(key, "")
+}
+
+// parsedEntry is the stored form of parse results. FetchedAt is when the parsed page was fetched,
+// so results never outlive their page, e.g. when a stale copy was parsed offline.
+type parsedEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Result    json.RawMessage `json:"result"`
+}
+
+// LoadParsed decodes the parse results cached for url by the given parser version into v,
+// reporting whether results of a page that is still fresh were found
+func LoadParsed(url string, version int, v any) bool {
+	entry, err := parsedStore.Load(parsedKey(url, version))
+	if errors.Is(err, ErrNotFound) {
+		return false
+	}
+	if err != nil {
+		slog.Warn("⚠️  Failed to load parsed cache entry", "url", url, "error", err)
+		return false
+	}
+
+	var parsed parsedEntry
+	if err := json.Unmarshal([]byte(entry.Content), &parsed); err != nil || parsed.FetchedAt.IsZero() {
+		slog.Debug("Parsed cache entry unreadable", "url", url, "error", err)
+		return false
+	}
+	if time.Since(parsed.FetchedAt) > TTLFor(url) {
+		slog.Debug("Parsed cache expired", "url", url)
+		return false
+	}
+
+	if err := json.Unmarshal(parsed.Result, v); err != nil {
+		slog.Warn("⚠️  Failed to decode parsed cache entry", "url", url, "error", err)
+		return false
+	}
+
+	slog.Debug("💾 Parsed cache hit", "url", url, "age", time.Since(parsed.FetchedAt).Round(time.Minute))
+	return true
+}
+
+// SaveParsed stores the parse results v of url for the given parser version. The results expire
+// with the cached page they were parsed from; without a cached page nothing is stored.
+func SaveParsed(url string, version int, v any) error {
+	page, err := store.Load(url)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load the cached page of %s: %w", url, err)
+	}
+
+	result, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode parse results for %s: %w", url, err)
+	}
+	data, err := json.Marshal(parsedEntry{FetchedAt: page.Timestamp, Result: result})
+	if err != nil {
+		return fmt.Errorf("failed to encode parse results for %s: %w", url, err)
+	}
+	if err := parsedStore.Save(parsedKey(url, version), string(data)); err != nil {
+		return err
+	}
+
+	removeOlderParsed(url, version)
+	return nil
+}
+
+// removeOlderParsed deletes the results earlier parser versions stored for url, which are never
+// read again. Failures are only logged: the results just saved are valid either way.
+func removeOlderParsed(url string, version int) {
+	for older := 1; older < version; older++ {
+		key := parsedKey(url, older)
+		if _, err := parsedStore.Load(key); err != nil {
+			continue
+		}
+		if err := parsedStore.Remove(parsedStore.Key(key)); err != nil {
+			slog.Debug("Failed to remove outdated parse results", "url", url, "version", older, "error", err)
+		}
+	}
+}
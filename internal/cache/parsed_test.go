@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestParsedRoundTrip(t *testing.T) {
+	previous := settings
+	t.Cleanup(func() { Configure(previous) })
+	if err := Configure(Settings{Dir: t.TempDir(), TTL: time.Hour, TransitTTL: time.Hour, TrainTTL: time.Hour}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	if err := InitCache(); err != nil {
+		t.Fatalf("InitCache failed: %v", err)
+	}
+
+	const url = "https://etrain.info/transit/BL-NED"
+	if err := SaveParsed(url, 1, []string{"KYN", "PUNE"}); err != nil {
+		t.Fatalf("SaveParsed failed: %v", err)
+	}
+	var stations []string
+	if LoadParsed(url, 1, &stations) {
+		t.Error("results were saved without a cached page to expire with")
+	}
+
+	if err := SaveToCache(url, "<html>ok</html>"); err != nil {
+		t.Fatalf("SaveToCache failed: %v", err)
+	}
+	if err := SaveParsed(url, 1, []string{"KYN", "PUNE"}); err != nil {
+		t.Fatalf("SaveParsed failed: %v", err)
+	}
+
+	if !LoadParsed(url, 1, &stations) || len(stations) != 2 || stations[0] != "KYN" {
+		t.Errorf("LoadParsed(v1) = %v, want [KYN PUNE]", stations)
+	}
+
+	// Results of another parser version are never served
+	if LoadParsed(url, 2, &stations) {
+		t.Error("LoadParsed(v2) served results saved by parser v1")
+	}
+
+	// Saving for a new parser version drops the results of the older one
+	if err := SaveParsed(url, 2, []string{"KYN"}); err != nil {
+		t.Fatalf("SaveParsed failed: %v", err)
+	}
+	if _, err := Find(ParsedStore(), parsedKey(url, 1)); err == nil {
+		t.Error("results of parser v1 are kept after parser v2 saved its own")
+	}
+	if !LoadParsed(url, 2, &stations) || len(stations) != 1 {
+		t.Errorf("LoadParsed(v2) = %v, want [KYN]", stations)
+	}
+
+	// Parse results live apart from pages
+	if _, err := Find(ActiveStore(), parsedKey(url, 2)); err == nil {
+		t.Error("parse results show up as a cached page")
+	}
+}
+
+func TestPurgeParsedByPageURL(t *testing.T) {
+	previous := settings
+	t.Cleanup(func() { Configure(previous) })
+	if err := Configure(Settings{Dir: t.TempDir(), TTL: time.Hour, TransitTTL: time.Hour, TrainTTL: time.Hour}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	if err := InitCache(); err != nil {
+		t.Fatalf("InitCache failed: %v", err)
+	}
+
+	urls := []string{"https://etrain.info/transit/BL-NED", "https://etrain.info/transit/BL-NED?page=2"}
+	for _, url := range urls {
+		if err := SaveToCache(url, "<html>ok</html>"); err != nil {
+			t.Fatalf("SaveToCache failed: %v", err)
+		}
+		if err := SaveParsed(url, 1, []string{"KYN"}); err != nil {
+			t.Fatalf("SaveParsed failed: %v", err)
+		}
+	}
+
+	// An anchored pattern matches the page URL, not the key parse results are stored under
+	options := PurgeOptions{URLPattern: regexp.MustCompile("NED$")}
+	for _, store := range []Store{ActiveStore(), ParsedStore()} {
+		removed, err := Purge(store, options)
+		if err != nil {
+			t.Fatalf("Purge failed: %v", err)
+		}
+		if len(removed) != 1 {
+			t.Errorf("Purge removed %d entries, want 1", len(removed))
+		}
+	}
+
+	var stations []string
+	if LoadParsed(urls[0], 1, &stations) {
+		t.Error("parse results of the purged page are still served")
+	}
+	if !LoadParsed(urls[1], 1, &stations) {
+		t.Error("parse results of a page not matching the pattern were purged")
+	}
+}
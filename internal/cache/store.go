@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"trains/internal/types"
+)
+
+const (
+	pagesNamespace  = "pages"  // Raw fetched pages
+	parsedNamespace = "parsed" // Parse results of pages, see SaveParsed
+)
+
+// ErrNotFound is returned by Store.Load when no entry exists for a URL
+var ErrNotFound = errors.New("cache entry not found")
+
+// Backend names a Store implementation
+type Backend string
+
+const (
+	BackendFile Backend = "file" // One compressed file per entry
+	BackendBolt Backend = "bolt" // Single-file embedded key-value store
+)
+
+// ParseBackend validates and normalizes a backend name
+func ParseBackend(name string) (Backend, error) {
+	switch backend := Backend(name); backend {
+	case BackendFile, BackendBolt:
+		return backend, nil
+	}
+	return "", fmt.Errorf("invalid cache backend '%s'. Valid options: file, bolt", name)
+}
+
+// Record is one stored entry as seen by Store.Scan
+type Record struct {
+	Key   string // Storage key, e.g. a file name
+	Size  int64  // Stored size in bytes
+	Entry types.CacheEntry
+	Err   error // Set when the stored data could not be decoded
+}
+
+// Store persists cache entries keyed by URL
+type Store interface {
+	// Load returns the entry for url, or an error wrapping ErrNotFound
+	Load(url string) (types.CacheEntry, error)
+
+	// Save stores content for url, replacing any earlier entry
+	Save(url, content string) error
+
+	// Scan calls fn for every stored entry, including ones that cannot be decoded
+	Scan(fn func(Record) error) error
+
+	// Remove deletes the entry stored under key
+	Remove(key string) error
+
+	// Key returns the storage key of the entry for url
+	Key(url string) string
+}
+
+// NewStore returns the store for pages with the given settings
+func NewStore(s Settings) Store {
+	return newNamespacedStore(s, pagesNamespace)
+}
+
+// newNamespacedStore returns a store whose entries are kept apart from other namespaces
+func newNamespacedStore(s Settings, namespace string) Store {
+	if s.Backend == BackendBolt {
+		return &BoltStore{Path: filepath.Join(s.Dir, boltFileName), Bucket: namespace}
+	}
+	if namespace == pagesNamespace {
+		// Pages live directly in the cache directory, as they always have
+		return FileStore{Dir: s.Dir}
+	}
+	return FileStore{Dir: filepath.Join(s.Dir, namespace)}
+}
+
+// ContentHash returns the hex SHA-256 of page content, as stored in CacheEntry.ContentHash
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// newEntry builds the entry stored for url
+func newEntry(url, content string) types.CacheEntry {
+	return types.CacheEntry{
+		URL:         url,
+		Content:     content,
+		Timestamp:   time.Now(),
+		ContentHash: ContentHash(content),
+	}
+}
+
+// encodeEntry serializes an entry as gzip-compressed JSON
+func encodeEntry(entry types.CacheEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(entry); err != nil {
+		return nil, fmt.Errorf("failed to encode cache entry for %s: %w", entry.URL, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress cache entry for %s: %w", entry.URL, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeEntry parses an entry written by encodeEntry, or plain JSON written by older versions
+func decodeEntry(data []byte) (types.CacheEntry, error) {
+	var entry types.CacheEntry
+
+	var reader io.Reader = bytes.NewReader(data)
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return entry, fmt.Errorf("invalid gzip data: %w", err)
+		}
+		reader = gz
+	}
+
+	if err := json.NewDecoder(reader).Decode(&entry); err != nil {
+		return entry, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	// Entries written before hashes were recorded have no hash to check
+	if entry.ContentHash != "" && entry.ContentHash != ContentHash(entry.Content) {
+		return entry, errors.New("content hash mismatch")
+	}
+	return entry, nil
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testStores returns one store of every backend, each in its own directory
+func testStores(t *testing.T) map[Backend]Store {
+	return map[Backend]Store{
+		BackendFile: NewStore(Settings{Dir: t.TempDir(), Backend: BackendFile}),
+		BackendBolt: NewStore(Settings{Dir: t.TempDir(), Backend: BackendBolt}),
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	const url = "https://etrain.info/transit/BL-NED"
+
+	for backend, store := range testStores(t) {
+		t.Run(string(backend), func(t *testing.T) {
+			if _, err := store.Load(url); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("Load() of missing entry = %v, want ErrNotFound", err)
+			}
+
+			if err := store.Save(url, "<html>BL-NED</html>"); err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			entry, err := store.Load(url)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if entry.Content != "<html>BL-NED</html>" || entry.ContentHash != ContentHash(entry.Content) {
+				t.Errorf("Load() = %+v, want the saved content with its hash", entry)
+			}
+
+			if err := store.Remove(store.Key(url)); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if _, err := store.Load(url); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Load() after Remove() = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreConcurrentSaves(t *testing.T) {
+	for backend, store := range testStores(t) {
+		t.Run(string(backend), func(t *testing.T) {
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					url := fmt.Sprintf("https://etrain.info/trains/BL-to-NED-via-X%d", i%2)
+					if err := store.Save(url, fmt.Sprintf("<html>%d</html>", i)); err != nil {
+						t.Errorf("Save() error = %v", err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			corrupt, err := Verify(store)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if len(corrupt) != 0 {
+				t.Errorf("Verify() found corrupt entries after concurrent saves: %+v", corrupt)
+			}
+		})
+	}
+}
+
+func TestFileStoreDetectsTampering(t *testing.T) {
+	const url = "https://etrain.info/transit/BL-NED"
+	dir := t.TempDir()
+	store := FileStore{Dir: dir}
+
+	// Store an entry whose hash does not match its content
+	entry := newEntry(url, "<html>original</html>")
+	entry.Content = "<html>tampered</html>"
+	data, err := encodeEntry(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, store.Key(url)), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Load(url); err == nil {
+		t.Error("Load() accepted an entry with a mismatching content hash")
+	}
+	corrupt, err := Verify(store)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(corrupt) != 1 {
+		t.Errorf("Verify() found %d corrupt entries, want 1", len(corrupt))
+	}
+}
+
+func TestFileStoreReadsLegacyEntries(t *testing.T) {
+	const url = "https://etrain.info/trains/BL-to-NED-via-KYN"
+	dir := t.TempDir()
+	if err := SaveEntry(dir, url, "<html>legacy</html>"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := FileStore{Dir: dir}
+	entry, err := store.Load(url)
+	if err != nil || entry.Content != "<html>legacy</html>" {
+		t.Fatalf("Load() of legacy entry = %+v, %v", entry, err)
+	}
+
+	// Saving replaces the plain JSON file with a compressed one
+	if err := store.Save(url, "<html>new</html>"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, EntryFileName(url))); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("legacy file still exists after Save(): %v", err)
+	}
+}
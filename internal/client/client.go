@@ -2,8 +2,10 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -15,10 +17,151 @@ type Fetcher interface {
 	Fetch(url string) (string, error)
 }
 
+// DefaultTimeout is the per-request timeout used when none is configured
+const DefaultTimeout = 30 * time.Second
+
+// HTTPStatusError is returned for responses with a non-2xx status code
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration // Parsed Retry-After header, 0 if absent
+}
+
+// Error implements error
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status %d %s for %s", e.StatusCode, http.StatusText(e.StatusCode), e.URL)
+}
+
+// NetworkFetcher fetches content directly from the network
+type NetworkFetcher struct {
+	Timeout time.Duration // Per-request timeout, DefaultTimeout if zero
+}
+
+// Fetch implements Fetcher
+func (f NetworkFetcher) Fetch(url string) (string, error) {
+	timeout := f.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return fetchWithTimeout(url, timeout)
+}
+
+// ErrOffline is returned in offline mode for pages that are not cached
+var ErrOffline = errors.New("page not cached and offline mode is enabled")
+
+// CachingFetcher serves content from the cache and falls back to Next on a miss
+type CachingFetcher struct {
+	Next         Fetcher
+	StaleIfError bool // Serve an expired entry when Next fails
+	Offline      bool // Never call Next, serve expired entries instead
+}
+
+// Fetch implements Fetcher
+func (f CachingFetcher) Fetch(url string) (string, error) {
+	// Try to load from cache first
+	if content, found := cache.LoadFromCache(url); found {
+		return content, nil
+	}
+	
+	if f.Offline {
+		if content, age, found := cache.LoadStale(url); found {
+			slog.Warn("⚠️  Offline: serving stale copy", "url", url, "age", age.Round(time.Minute))
+			return content, nil
+		}
+		return "", fmt.Errorf("error fetching %s: %w", url, ErrOffline)
+	}
+	
+	// Fetch from the next fetcher in the chain
+	slog.Info("🌐 Fetching from network", "url", url)
+	content, err := f.Next.Fetch(url)
+	if err != nil {
+		if f.StaleIfError {
+			if content, age, found := cache.LoadStale(url); found {
+				slog.Warn("⚠️  Network fetch failed, serving stale copy", "url", url, "age", age.Round(time.Minute), "error", err)
+				return content, nil
+			}
+		}
+		return "", err
+	}
+	
+	// Save to cache
+	if err := cache.SaveToCache(url, content); err != nil {
+		slog.Warn("⚠️  Failed to save to cache", "url", url, "error", err)
+		// Don't fail the entire operation if caching fails
+	}
+	
+	return content, nil
+}
+
+// ParsedCache stores parse results of pages so that cached pages need not be parsed again
+type ParsedCache interface {
+	// LoadParsed decodes fresh results cached for url by parser version into v
+	LoadParsed(url string, version int, v any) bool
+	
+	// SaveParsed stores the results v of parsing url with parser version
+	SaveParsed(url string, version int, v any)
+}
+
+// LoadParsed implements ParsedCache
+func (f CachingFetcher) LoadParsed(url string, version int, v any) bool {
+	return cache.LoadParsed(url, version, v)
+}
+
+// SaveParsed implements ParsedCache
+func (f CachingFetcher) SaveParsed(url string, version int, v any) {
+	if err := cache.SaveParsed(url, version, v); err != nil {
+		slog.Warn("⚠️  Failed to save parse results to cache", "url", url, "error", err)
+	}
+}
+
+// ReplayFetcher serves content from previously recorded pages and never touches the network
+type ReplayFetcher struct {
+	Pages map[string]string // Page content keyed by URL
+}
+
+// Fetch implements Fetcher
+func (f ReplayFetcher) Fetch(url string) (string, error) {
+	content, ok := f.Pages[url]
+	if !ok {
+		return "", fmt.Errorf("no recorded page for URL %s", url)
+	}
+	return content, nil
+}
+
+// Recorder stores pages fetched during a run
+type Recorder interface {
+	Record(url, content string) error
+}
+
+// RecordingFetcher records every page returned by Next
+type RecordingFetcher struct {
+	Next     Fetcher
+	Recorder Recorder
+}
+
+// Fetch implements Fetcher
+func (f RecordingFetcher) Fetch(url string) (string, error) {
+	content, err := f.Next.Fetch(url)
+	if err != nil {
+		return "", err
+	}
+	
+	if err := f.Recorder.Record(url, content); err != nil {
+		return "", fmt.Errorf("failed to record %s: %w", url, err)
+	}
+	
+	return content, nil
+}
+
 // FetchFromNetwork fetches content from network with timeout
 func FetchFromNetwork(url string) (string, error) {
+	return fetchWithTimeout(url, DefaultTimeout)
+}
+
+// fetchWithTimeout fetches content from network, treating non-2xx responses as errors
+func fetchWithTimeout(url string, timeout time.Duration) (string, error) {
 	// Create context with timeout for the request
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -32,6 +175,16 @@ func FetchFromNetwork(url string) (string, error) {
 	}
 	defer resp.Body.Close()
 	
+	// Error pages must never be parsed or cached as results
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return "", &HTTPStatusError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response body from %s: %w", url, err)
@@ -40,25 +193,7 @@ func FetchFromNetwork(url string) (string, error) {
 	return string(body), nil
 }
 
-// FetchWithCache fetches content with caching support
+// FetchWithCache fetches content with caching support, falling back to stale entries on failure
 func FetchWithCache(url string) (string, error) {
-	// Try to load from cache first
-	if content, found := cache.LoadFromCache(url); found {
-		return content, nil
-	}
-	
-	// Fetch from network
-	fmt.Printf("🌐 Fetching from network: %s\n", url)
-	content, err := FetchFromNetwork(url)
-	if err != nil {
-		return "", err
-	}
-	
-	// Save to cache
-	if err := cache.SaveToCache(url, content); err != nil {
-		fmt.Printf("⚠️  Warning: failed to save to cache: %v\n", err)
-		// Don't fail the entire operation if caching fails
-	}
-	
-	return content, nil
-}
\ No newline at end of file
+	return CachingFetcher{Next: NetworkFetcher{}, StaleIfError: true}.Fetch(url)
+}
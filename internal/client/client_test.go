@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"trains/internal/cache"
+)
+
+// useExpiredCache points the cache at an empty directory in which every entry is already expired
+func useExpiredCache(t *testing.T) {
+	t.Helper()
+	previous := cache.CurrentSettings()
+	t.Cleanup(func() { cache.Configure(previous) })
+
+	if err := cache.Configure(cache.Settings{Dir: t.TempDir(), TTL: time.Nanosecond, TransitTTL: time.Nanosecond, TrainTTL: time.Nanosecond}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+}
+
+func TestCachingFetcherStaleIfError(t *testing.T) {
+	useExpiredCache(t)
+	server, _ := newFlakyServer(t, 0, http.StatusOK, "")
+	url := server.URL + "/transit/BL-NED"
+
+	// Populate the cache, then let the server fail
+	if _, err := (CachingFetcher{Next: NetworkFetcher{}}).Fetch(url); err != nil {
+		t.Fatalf("initial Fetch() error = %v", err)
+	}
+	server.Close()
+
+	if _, err := (CachingFetcher{Next: NetworkFetcher{}}).Fetch(url); err == nil {
+		t.Error("Fetch() without StaleIfError served an expired entry")
+	}
+
+	content, err := CachingFetcher{Next: NetworkFetcher{}, StaleIfError: true}.Fetch(url)
+	if err != nil || content != "<html>ok</html>" {
+		t.Errorf("Fetch() with StaleIfError = %q, %v; want the stale page", content, err)
+	}
+}
+
+func TestCachingFetcherOffline(t *testing.T) {
+	useExpiredCache(t)
+	server, requests := newFlakyServer(t, 0, http.StatusOK, "")
+	url := server.URL + "/trains/BL-to-NED-via-KYN"
+
+	if _, err := (CachingFetcher{Next: NetworkFetcher{}, Offline: true}).Fetch(url); !errors.Is(err, ErrOffline) {
+		t.Errorf("Fetch() of uncached page = %v, want ErrOffline", err)
+	}
+
+	if _, err := (CachingFetcher{Next: NetworkFetcher{}}).Fetch(url); err != nil {
+		t.Fatalf("initial Fetch() error = %v", err)
+	}
+
+	content, err := CachingFetcher{Next: NetworkFetcher{}, Offline: true}.Fetch(url)
+	if err != nil || content != "<html>ok</html>" {
+		t.Errorf("offline Fetch() = %q, %v; want the stale page", content, err)
+	}
+	if *requests != 1 {
+		t.Errorf("server got %d requests, want 1 (offline mode must not fetch)", *requests)
+	}
+}
+
+func TestParseResultsOfStaleCopyExpire(t *testing.T) {
+	previous := cache.CurrentSettings()
+	t.Cleanup(func() { cache.Configure(previous) })
+	ttl := 200 * time.Millisecond
+	if err := cache.Configure(cache.Settings{Dir: t.TempDir(), TTL: ttl, TransitTTL: ttl, TrainTTL: ttl}); err != nil {
+		t.Fatalf("Configure failed: %v", err)
+	}
+	if err := cache.InitCache(); err != nil {
+		t.Fatalf("InitCache failed: %v", err)
+	}
+
+	const url = "https://etrain.info/transit/BL-NED"
+	network := &countingFetcher{}
+	online := CachingFetcher{Next: network}
+	if _, err := online.Fetch(url); err != nil {
+		t.Fatalf("initial Fetch() error = %v", err)
+	}
+	time.Sleep(ttl + 50*time.Millisecond)
+
+	// Parse the stale copy served offline
+	offline := CachingFetcher{Next: network, Offline: true}
+	if _, err := offline.Fetch(url); err != nil {
+		t.Fatalf("offline Fetch() error = %v", err)
+	}
+	offline.SaveParsed(url, 1, "parsed stale copy")
+
+	// The results are as old as the copy, so the next run fetches the page again
+	var result string
+	if online.LoadParsed(url, 1, &result) {
+		t.Error("LoadParsed() served results of a stale copy as fresh")
+	}
+	if _, err := online.Fetch(url); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if network.calls != 2 {
+		t.Errorf("network fetched %d times, want 2", network.calls)
+	}
+
+	online.SaveParsed(url, 1, "parsed fresh copy")
+	if !online.LoadParsed(url, 1, &result) || result != "parsed fresh copy" {
+		t.Errorf("LoadParsed() = %q, want the results of the fresh copy", result)
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by all fetches of a run
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Maximum number of stored tokens
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing rps requests per second with the given burst
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a request may be made
+func (l *RateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// Reserve a token; a negative balance is the time this caller has to wait
+	l.tokens--
+	wait := time.Duration(0)
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
+
+// RateLimitedFetcher waits for the shared limiter before each fetch of Next
+type RateLimitedFetcher struct {
+	Next    Fetcher
+	Limiter *RateLimiter
+}
+
+// Fetch implements Fetcher
+func (f RateLimitedFetcher) Fetch(url string) (string, error) {
+	f.Limiter.Wait()
+	return f.Next.Fetch(url)
+}
+
+// FetchAll fetches urls through a bounded pool of workers, returning contents in input order
+func FetchAll(fetcher Fetcher, urls []string, concurrency int) ([]string, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	contents := make([]string, len(urls))
+	errs := make([]error, len(urls))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency && worker < len(urls); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				contents[i], errs[i] = fetcher.Fetch(urls[i])
+			}
+		}()
+	}
+
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Report the first failure in input order so errors are deterministic
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %s: %w", urls[i], err)
+		}
+	}
+
+	return contents, nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingFetcher returns the URL as content and counts calls
+type countingFetcher struct {
+	calls int32
+}
+
+func (f *countingFetcher) Fetch(url string) (string, error) {
+	atomic.AddInt32(&f.calls, 1)
+	if url == "bad" {
+		return "", fmt.Errorf("boom")
+	}
+	return "content of " + url, nil
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	limiter := NewRateLimiter(50, 1)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		limiter.Wait()
+	}
+
+	// The first token is available immediately, the other four take 20ms each
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("5 requests at 50 rps took %v, want at least 70ms", elapsed)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	fetcher := &countingFetcher{}
+	urls := []string{"a", "b", "c", "d", "e"}
+
+	contents, err := FetchAll(fetcher, urls, 3)
+	if err != nil {
+		t.Fatalf("FetchAll() unexpected error: %v", err)
+	}
+	for i, url := range urls {
+		if contents[i] != "content of "+url {
+			t.Errorf("contents[%d] = %q, want %q", i, contents[i], "content of "+url)
+		}
+	}
+	if fetcher.calls != int32(len(urls)) {
+		t.Errorf("fetcher called %d times, want %d", fetcher.calls, len(urls))
+	}
+
+	if _, err := FetchAll(fetcher, []string{"a", "bad", "c"}, 2); err == nil {
+		t.Error("FetchAll() expected error for failing URL but got none")
+	}
+}
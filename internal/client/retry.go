@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy configures how RetryingFetcher retries transient failures
+type RetryPolicy struct {
+	MaxRetries        int           // Retries after the first attempt, 0 disables retrying
+	BaseDelay         time.Duration // Delay before the first retry, doubled for each further retry
+	MaxDelay          time.Duration // Upper bound for a single delay
+	Jitter            float64       // Fraction (0-1) of each delay that is randomized
+	RespectRetryAfter bool          // Wait at least as long as a Retry-After header asks
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:        3,
+		BaseDelay:         time.Second,
+		MaxDelay:          30 * time.Second,
+		Jitter:            0.2,
+		RespectRetryAfter: true,
+	}
+}
+
+// Validate checks that the policy values are usable
+func (p RetryPolicy) Validate() error {
+	if p.MaxRetries < 0 {
+		return fmt.Errorf("invalid retry count %d: must not be negative", p.MaxRetries)
+	}
+	if p.BaseDelay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("invalid retry delay: must not be negative")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("invalid retry jitter %g: must be between 0 and 1", p.Jitter)
+	}
+	return nil
+}
+
+// backoff returns the delay before the given retry (0 = first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 0; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	// Randomize part of the delay so concurrent clients don't retry in lockstep
+	if p.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * p.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// RetryingFetcher retries transient failures of Next with exponential backoff
+type RetryingFetcher struct {
+	Next   Fetcher
+	Policy RetryPolicy
+
+	sleep func(time.Duration) // Replaced in tests, time.Sleep if nil
+}
+
+// Fetch implements Fetcher
+func (f RetryingFetcher) Fetch(url string) (string, error) {
+	sleep := f.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	for retry := 0; ; retry++ {
+		content, err := f.Next.Fetch(url)
+		if err == nil {
+			return content, nil
+		}
+
+		if !IsTransient(err) || retry >= f.Policy.MaxRetries {
+			return "", err
+		}
+
+		delay := f.Policy.backoff(retry)
+
+		var statusErr *HTTPStatusError
+		if f.Policy.RespectRetryAfter && errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+			// Don't stall the whole run on a server asking us to come back much later
+			if f.Policy.MaxDelay > 0 && statusErr.RetryAfter > f.Policy.MaxDelay {
+				return "", fmt.Errorf("giving up on %s: server asked to retry after %v (max delay %v): %w",
+					url, statusErr.RetryAfter, f.Policy.MaxDelay, err)
+			}
+			delay = statusErr.RetryAfter
+		}
+
+		slog.Warn("⏳ Retrying", "url", url, "delay", delay.Round(time.Millisecond),
+			"retry", retry+1, "max_retries", f.Policy.MaxRetries, "error", err)
+		sleep(delay)
+	}
+}
+
+// IsTransient reports whether a fetch error is worth retrying
+func IsTransient(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFlakyServer returns a server that fails with status for the first failures requests
+func newFlakyServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			http.Error(w, "try again later", status)
+			return
+		}
+		w.Write([]byte("<html>ok</html>"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestNetworkFetcherRejectsNon2xx(t *testing.T) {
+	server, _ := newFlakyServer(t, 1, http.StatusServiceUnavailable, "7")
+
+	_, err := NetworkFetcher{}.Fetch(server.URL)
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("Fetch() error = %v, want *HTTPStatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.RetryAfter != 7*time.Second {
+		t.Errorf("got status %d retry-after %v, want 503 and 7s", statusErr.StatusCode, statusErr.RetryAfter)
+	}
+}
+
+func TestRetryingFetcher(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int32
+		status        int
+		retryAfter    string
+		policy        RetryPolicy
+		expectError   bool
+		expectedCalls int32
+		minDelay      time.Duration
+	}{
+		{
+			name:          "Recovers from transient 503",
+			failures:      2,
+			status:        http.StatusServiceUnavailable,
+			policy:        RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second},
+			expectedCalls: 3,
+		},
+		{
+			name:          "Gives up after max retries",
+			failures:      10,
+			status:        http.StatusBadGateway,
+			policy:        RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second},
+			expectError:   true,
+			expectedCalls: 3,
+		},
+		{
+			name:          "Does not retry 404",
+			failures:      1,
+			status:        http.StatusNotFound,
+			policy:        RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second},
+			expectError:   true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "Honors Retry-After on 429",
+			failures:      1,
+			status:        http.StatusTooManyRequests,
+			retryAfter:    "2",
+			policy:        RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Minute, RespectRetryAfter: true},
+			expectedCalls: 2,
+			minDelay:      2 * time.Second,
+		},
+		{
+			name:          "Gives up when Retry-After exceeds max delay",
+			failures:      1,
+			status:        http.StatusTooManyRequests,
+			retryAfter:    "120",
+			policy:        RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: time.Minute, RespectRetryAfter: true},
+			expectError:   true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newFlakyServer(t, tt.failures, tt.status, tt.retryAfter)
+
+			var slept time.Duration
+			fetcher := RetryingFetcher{
+				Next:   NetworkFetcher{},
+				Policy: tt.policy,
+				sleep:  func(d time.Duration) { slept += d },
+			}
+
+			content, err := fetcher.Fetch(server.URL)
+
+			if tt.expectError && err == nil {
+				t.Errorf("Fetch() expected error but got content %q", content)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Fetch() unexpected error: %v", err)
+			}
+			if got := atomic.LoadInt32(requests); got != tt.expectedCalls {
+				t.Errorf("server saw %d requests, want %d", got, tt.expectedCalls)
+			}
+			if slept < tt.minDelay {
+				t.Errorf("slept %v, want at least %v", slept, tt.minDelay)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for retry, want := range expected {
+		if got := policy.backoff(retry); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", retry, got, want)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < time.Second || got > 2*time.Second {
+			t.Fatalf("backoff(1) with jitter = %v, want within [1s, 2s]", got)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"trains/internal/cache"
+)
+
+const (
+	// BundleVersion is the fixture bundle format written by Recorder
+	BundleVersion = 1
+
+	manifestFile = "manifest.json"
+)
+
+// Manifest describes the pages stored in a fixture bundle
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Pages     []Page    `json:"pages"`
+}
+
+// Page is a single recorded page in a fixture bundle
+type Page struct {
+	URL        string    `json:"url"`
+	File       string    `json:"file"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// Recorder saves fetched pages into a fixture bundle directory
+type Recorder struct {
+	dir      string
+	mu       sync.Mutex
+	manifest Manifest
+}
+
+// NewRecorder creates or reopens the fixture bundle in dir
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create fixture directory %s: %w", dir, err)
+	}
+
+	manifest, err := readManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		manifest = Manifest{Version: BundleVersion, CreatedAt: time.Now()}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{dir: dir, manifest: manifest}, nil
+}
+
+// Record stores the page for URL in the bundle, replacing any earlier recording
+func (r *Recorder) Record(url, content string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := cache.SaveEntry(r.dir, url, content); err != nil {
+		return err
+	}
+
+	page := Page{URL: url, File: cache.EntryFileName(url), RecordedAt: time.Now()}
+	replaced := false
+	for i := range r.manifest.Pages {
+		if r.manifest.Pages[i].URL == url {
+			r.manifest.Pages[i] = page
+			replaced = true
+		}
+	}
+	if !replaced {
+		r.manifest.Pages = append(r.manifest.Pages, page)
+		sort.Slice(r.manifest.Pages, func(i, j int) bool { return r.manifest.Pages[i].URL < r.manifest.Pages[j].URL })
+	}
+
+	return writeManifest(r.dir, r.manifest)
+}
+
+// Load reads every page of the fixture bundle in dir, keyed by URL
+func Load(dir string) (map[string]string, error) {
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]string, len(manifest.Pages))
+	for _, page := range manifest.Pages {
+		// Recorded entries never expire, so their timestamp is not checked
+		entry, err := cache.LoadEntry(dir, page.URL)
+		if err != nil {
+			return nil, fmt.Errorf("fixture bundle %s is incomplete: %w", dir, err)
+		}
+		pages[page.URL] = entry.Content
+	}
+
+	return pages, nil
+}
+
+// readManifest reads and validates the manifest of the bundle in dir
+func readManifest(dir string) (Manifest, error) {
+	var manifest Manifest
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read fixture manifest in %s: %w", dir, err)
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse fixture manifest in %s: %w", dir, err)
+	}
+
+	if manifest.Version != BundleVersion {
+		return manifest, fmt.Errorf("unsupported fixture bundle version %d in %s (want %d)", manifest.Version, dir, BundleVersion)
+	}
+
+	return manifest, nil
+}
+
+// writeManifest writes the manifest of the bundle in dir
+func writeManifest(dir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture manifest: %w", err)
+	}
+
+	if err := cache.WriteFileAtomic(filepath.Join(dir, manifestFile), data); err != nil {
+		return fmt.Errorf("failed to write fixture manifest in %s: %w", dir, err)
+	}
+
+	return nil
+}
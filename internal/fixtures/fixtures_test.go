@@ -0,0 +1,63 @@
+package fixtures
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	recorder, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatalf("NewRecorder() unexpected error: %v", err)
+	}
+	if err := recorder.Record("https://etrain.info/transit/BL-NED?page=1", "<html>page 1</html>"); err != nil {
+		t.Fatalf("Record() unexpected error: %v", err)
+	}
+	if err := recorder.Record("https://etrain.info/transit/BL-NED?page=1", "<html>page 1 again</html>"); err != nil {
+		t.Fatalf("Record() unexpected error: %v", err)
+	}
+
+	// Reopening the bundle appends to the existing manifest
+	recorder, err = NewRecorder(dir)
+	if err != nil {
+		t.Fatalf("NewRecorder() on existing bundle unexpected error: %v", err)
+	}
+	if err := recorder.Record("https://etrain.info/transit/BL-NED?page=2", "<html>page 2</html>"); err != nil {
+		t.Fatalf("Record() unexpected error: %v", err)
+	}
+
+	pages, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() unexpected error: %v", err)
+	}
+	if len(pages) != 2 {
+		t.Fatalf("Load() returned %d pages, want 2", len(pages))
+	}
+	if got := pages["https://etrain.info/transit/BL-NED?page=1"]; got != "<html>page 1 again</html>" {
+		t.Errorf("page 1 = %q, want the latest recording", got)
+	}
+
+	// The manifest and entries are renamed into place, leaving no temporary files behind
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read bundle directory: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("bundle holds %d files, want 2 entries and the manifest", len(files))
+	}
+}
+
+func TestLoadRejectsUnknownVersion(t *testing.T) {
+	dir := t.TempDir()
+	manifest := []byte(`{"version": 99, "pages": []}`)
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), manifest, 0644); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	if _, err := Load(dir); err == nil {
+		t.Error("Load() expected error for unknown bundle version but got none")
+	}
+}
@@ -0,0 +1,161 @@
+// Package logging sets up the leveled, structured logger shared by all packages.
+// Packages log through log/slog; Setup decides what is shown and in which format.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Format is a log output format
+type Format string
+
+const (
+	FormatText Format = "text" // Human-readable lines: the message followed by key=value pairs
+	FormatJSON Format = "json" // One JSON object per record
+)
+
+// ParseFormat validates and normalizes a log format name
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(name))); format {
+	case FormatText, FormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid log format '%s'. Valid options: text, json", name)
+}
+
+// Level returns the minimum level logged for the --verbose and --quiet flags
+func Level(verbose, quiet bool) slog.Level {
+	switch {
+	case verbose:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// textOutput is set while Setup has installed the text format
+var textOutput bool
+
+// Setup installs the default logger writing records of at least level to w
+func Setup(w io.Writer, level slog.Level, format Format) {
+	textOutput = format != FormatJSON
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if format == FormatJSON {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = &textHandler{w: w, level: level, mu: &sync.Mutex{}}
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// init keeps library output on stderr at the default level until Setup is called
+func init() {
+	Setup(os.Stderr, slog.LevelInfo, FormatText)
+}
+
+// Debugf logs a formatted message at debug level
+func Debugf(format string, args ...any) {
+	slog.Debug(fmt.Sprintf(format, args...))
+}
+
+// Infof logs a formatted message at info level, used for progress messages
+func Infof(format string, args ...any) {
+	slog.Info(fmt.Sprintf(format, args...))
+}
+
+// Warnf logs a formatted message at warn level
+func Warnf(format string, args ...any) {
+	slog.Warn(fmt.Sprintf(format, args...))
+}
+
+// Separator ends a block of progress messages with a blank line in text format
+func Separator() {
+	if textOutput {
+		slog.Info("")
+	}
+}
+
+// textHandler writes the message of each record followed by its attributes as key=value.
+// Messages already carry their own emoji markers, so no time or level is printed.
+type textHandler struct {
+	w      io.Writer
+	level  slog.Level
+	attrs  []slog.Attr
+	prefix string // Group prefix for attribute keys
+	mu     *sync.Mutex
+}
+
+// Enabled implements slog.Handler
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+// Handle implements slog.Handler
+func (h *textHandler) Handle(_ context.Context, record slog.Record) error {
+	var line strings.Builder
+	line.WriteString(record.Message)
+
+	for _, attr := range h.attrs {
+		appendAttr(&line, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		appendAttr(&line, h.prefix, attr)
+		return true
+	})
+	line.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line.String())
+	return err
+}
+
+// WithAttrs implements slog.Handler
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		attr.Key = h.prefix + attr.Key
+		clone.attrs = append(clone.attrs, attr)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendAttr writes " key=value", quoting values that contain spaces and flattening groups
+func appendAttr(line *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		for _, member := range attr.Value.Group() {
+			appendAttr(line, prefix+attr.Key+".", member)
+		}
+		return
+	}
+
+	value := attr.Value.String()
+	if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(line, " %s%s=%s", prefix, attr.Key, value)
+}
@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestTextOutput(t *testing.T) {
+	var buf bytes.Buffer
+	Setup(&buf, Level(false, false), FormatText)
+	defer Setup(os.Stderr, slog.LevelInfo, FormatText)
+
+	slog.Debug("hidden")
+	slog.Info("🌐 Fetching from network", "url", "https://etrain.info/transit/BL-NED")
+	slog.Warn("⚠️  Skipped", "detail", `source "X"`)
+	Separator()
+
+	want := "🌐 Fetching from network url=https://etrain.info/transit/BL-NED\n" +
+		"⚠️  Skipped detail=\"source \\\"X\\\"\"\n" +
+		"\n"
+	if buf.String() != want {
+		t.Errorf("text output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	Setup(&buf, Level(false, true), FormatJSON)
+	defer Setup(os.Stderr, slog.LevelInfo, FormatText)
+
+	Infof("progress %d", 1)
+	Separator()
+	Warnf("warning %d", 2)
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("quiet JSON output %q is not a single record: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["msg"] != "warning 2" {
+		t.Errorf("record = %v, want the warning only", record)
+	}
+
+	if Level(true, false) != slog.LevelDebug {
+		t.Errorf("Level(verbose) = %v, want debug", Level(true, false))
+	}
+}
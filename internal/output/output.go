@@ -0,0 +1,343 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"trains/internal/cache"
+	"trains/internal/parser"
+	"trains/internal/stations"
+	"trains/internal/types"
+)
+
+// Format is an output format for command results
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat validates and normalizes an output format name
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(name))); format {
+	case FormatText, FormatJSON, FormatCSV, FormatNDJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid output format '%s'. Valid options: text, json, csv, ndjson", name)
+}
+
+// Leg is the machine-readable form of one train in a connection
+type Leg struct {
+	Number          string `json:"number"`
+	Name            string `json:"name"`
+	Type            string `json:"type"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Departure       string `json:"departure"`
+	Arrival         string `json:"arrival"`
+	TravelTime      string `json:"travel_time"`
+	RunningDays     string `json:"running_days"`
+	RunningDaysMask int    `json:"running_days_mask"` // bit 0 = Sun ... bit 6 = Sat
+
+	// Only set when the page lists them
+	Classes    []string `json:"classes,omitempty"`
+	Pantry     bool     `json:"pantry,omitempty"`
+	DistanceKm int      `json:"distance_km,omitempty"`
+
+	// Absolute times in IST, only set when searching by date
+	DepartureAt *time.Time `json:"departure_at,omitempty"`
+	ArrivalAt   *time.Time `json:"arrival_at,omitempty"`
+}
+
+// Connection is the machine-readable form of types.RouteConnection
+type Connection struct {
+	Train1         Leg    `json:"train1"`
+	Train2         Leg    `json:"train2"`
+	Connection     string `json:"connection"`
+	LayoverMinutes int    `json:"layover_minutes"`
+	TotalMinutes   int    `json:"total_minutes"`
+	TotalTime      string `json:"total_time"`
+	CommonDaysMask int    `json:"common_days_mask"` // Departure days from the source station
+
+	// Day offsets relative to the day train1 departs (0 = same day)
+	TransitArrivalDay   int `json:"transit_arrival_day"`
+	TransitDepartureDay int `json:"transit_departure_day"`
+
+	BestFor []string `json:"best_for,omitempty"` // Pareto criteria won, only with --pareto
+}
+
+// TransitRoute is the machine-readable form of types.TransitRoute
+type TransitRoute struct {
+	SourceStation      string `json:"source_station"`
+	SourceStationCode  string `json:"source_station_code"`
+	SourceTrainCount   int    `json:"source_train_count"`
+	TransitStation     string `json:"transit_station"`
+	TransitStationCode string `json:"transit_station_code"`
+	TransitTrainCount  int    `json:"transit_train_count"`
+	DestStation        string `json:"dest_station"`
+	DestStationCode    string `json:"dest_station_code"`
+	TotalTrainCount    int    `json:"total_train_count"`
+	Distance           string `json:"distance"`
+	DistanceKm         int    `json:"distance_km"`
+	URL                string `json:"url"`
+}
+
+// Station is the machine-readable form of stations.Station
+type Station struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// CacheEntry is the machine-readable form of cache.EntryInfo
+type CacheEntry struct {
+	URL        string    `json:"url"`
+	Key        string    `json:"key"`
+	CachedAt   time.Time `json:"cached_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	SizeBytes  int64     `json:"size_bytes"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// NewLeg converts TrainData to its machine-readable form
+func NewLeg(train types.TrainData) Leg {
+	return Leg{
+		Number:          train.Number,
+		Name:            train.Name,
+		Type:            train.Type,
+		From:            train.SourceStationCode,
+		To:              train.DestStationCode,
+		Departure:       train.SourceTime,
+		Arrival:         train.DestTime,
+		TravelTime:      train.TravelTime,
+		RunningDays:     parser.FormatRunningDays(train.RunningDays),
+		RunningDaysMask: parser.RunningDaysMask(train.RunningDays),
+		Classes:         train.Classes,
+		Pantry:          train.Pantry,
+		DistanceKm:      train.Distance,
+	}
+}
+
+// NewConnection converts a RouteConnection to its machine-readable form
+func NewConnection(conn types.RouteConnection) Connection {
+	connection := Connection{
+		Train1:         NewLeg(conn.Train1),
+		Train2:         NewLeg(conn.Train2),
+		Connection:     conn.Connection,
+		LayoverMinutes: conn.LayoverMinutes,
+		TotalMinutes:   conn.TotalMinutes,
+		TotalTime:      conn.TotalTime,
+		CommonDaysMask: int(conn.RunningDays),
+
+		TransitArrivalDay:   conn.TransitArrivalDay,
+		TransitDepartureDay: conn.TransitDepartureDay,
+
+		BestFor: conn.BestFor,
+	}
+	if schedule := conn.Schedule; schedule != nil {
+		connection.Train1.DepartureAt, connection.Train1.ArrivalAt = &schedule.Train1Departure, &schedule.Train1Arrival
+		connection.Train2.DepartureAt, connection.Train2.ArrivalAt = &schedule.Train2Departure, &schedule.Train2Arrival
+	}
+	return connection
+}
+
+// NewTransitRoute converts a TransitRoute to its machine-readable form
+func NewTransitRoute(route types.TransitRoute) TransitRoute {
+	return TransitRoute{
+		SourceStation:      route.SourceStation,
+		SourceStationCode:  route.SourceStationCode,
+		SourceTrainCount:   route.SourceTrainCount,
+		TransitStation:     route.TransitStation,
+		TransitStationCode: route.TransitStationCode,
+		TransitTrainCount:  route.TransitTrainCount,
+		DestStation:        route.DestStation,
+		DestStationCode:    route.DestStationCode,
+		TotalTrainCount:    route.SourceTrainCount + route.TransitTrainCount,
+		Distance:           route.Distance,
+		DistanceKm:         parser.ParseDistanceKm(route.Distance),
+		URL:                parser.EtrainBaseURL + route.ShowLink,
+	}
+}
+
+// NewCacheEntry converts cache entry info to its machine-readable form
+func NewCacheEntry(info cache.EntryInfo) CacheEntry {
+	entry := CacheEntry{
+		URL:       info.URL,
+		Key:       info.Key,
+		CachedAt:  info.Timestamp,
+		SizeBytes: info.Size,
+		Status:    string(info.Status),
+	}
+	if !info.Timestamp.IsZero() {
+		entry.AgeSeconds = int64(info.Age().Seconds())
+	}
+	if info.Err != nil {
+		entry.Error = info.Err.Error()
+	}
+	return entry
+}
+
+var connectionCSVHeader = []string{
+	"train1_number", "train1_name", "train1_from", "train1_to", "train1_departure", "train1_arrival", "train1_running_days_mask",
+	"train2_number", "train2_name", "train2_from", "train2_to", "train2_departure", "train2_arrival", "train2_running_days_mask",
+	"connection", "layover_minutes", "total_minutes", "common_days_mask",
+	"transit_arrival_day", "transit_departure_day", "best_for",
+	"train1_departure_at", "train1_arrival_at", "train2_departure_at", "train2_arrival_at",
+}
+
+// csvRecord flattens a Connection into a CSV row matching connectionCSVHeader
+func (c Connection) csvRecord() []string {
+	return []string{
+		c.Train1.Number, c.Train1.Name, c.Train1.From, c.Train1.To, c.Train1.Departure, c.Train1.Arrival, strconv.Itoa(c.Train1.RunningDaysMask),
+		c.Train2.Number, c.Train2.Name, c.Train2.From, c.Train2.To, c.Train2.Departure, c.Train2.Arrival, strconv.Itoa(c.Train2.RunningDaysMask),
+		c.Connection, strconv.Itoa(c.LayoverMinutes), strconv.Itoa(c.TotalMinutes), strconv.Itoa(c.CommonDaysMask),
+		strconv.Itoa(c.TransitArrivalDay), strconv.Itoa(c.TransitDepartureDay), strings.Join(c.BestFor, ";"),
+		formatTimestamp(c.Train1.DepartureAt), formatTimestamp(c.Train1.ArrivalAt), formatTimestamp(c.Train2.DepartureAt), formatTimestamp(c.Train2.ArrivalAt),
+	}
+}
+
+// formatTimestamp formats an optional time as RFC 3339, or as an empty string when unset
+func formatTimestamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+var transitRouteCSVHeader = []string{
+	"source_station", "source_station_code", "source_train_count",
+	"transit_station", "transit_station_code", "transit_train_count",
+	"dest_station", "dest_station_code", "total_train_count", "distance_km", "url",
+}
+
+// csvRecord flattens a TransitRoute into a CSV row matching transitRouteCSVHeader
+func (t TransitRoute) csvRecord() []string {
+	return []string{
+		t.SourceStation, t.SourceStationCode, strconv.Itoa(t.SourceTrainCount),
+		t.TransitStation, t.TransitStationCode, strconv.Itoa(t.TransitTrainCount),
+		t.DestStation, t.DestStationCode, strconv.Itoa(t.TotalTrainCount), strconv.Itoa(t.DistanceKm), t.URL,
+	}
+}
+
+var cacheEntryCSVHeader = []string{"url", "key", "cached_at", "age_seconds", "size_bytes", "status", "error"}
+
+// csvRecord flattens a CacheEntry into a CSV row matching cacheEntryCSVHeader
+func (c CacheEntry) csvRecord() []string {
+	cachedAt := ""
+	if !c.CachedAt.IsZero() {
+		cachedAt = c.CachedAt.Format(time.RFC3339)
+	}
+	return []string{
+		c.URL, c.Key, cachedAt, strconv.FormatInt(c.AgeSeconds, 10), strconv.FormatInt(c.SizeBytes, 10), c.Status, c.Error,
+	}
+}
+
+// WriteConnections writes connections to w in a machine-readable format
+func WriteConnections(w io.Writer, format Format, connections []types.RouteConnection) error {
+	records := make([]Connection, 0, len(connections))
+	for _, conn := range connections {
+		records = append(records, NewConnection(conn))
+	}
+
+	if format == FormatCSV {
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, record.csvRecord())
+		}
+		return writeCSV(w, connectionCSVHeader, rows)
+	}
+	return writeJSON(w, format, records)
+}
+
+// WriteTransitRoutes writes transit routes to w in a machine-readable format
+func WriteTransitRoutes(w io.Writer, format Format, routes []types.TransitRoute) error {
+	records := make([]TransitRoute, 0, len(routes))
+	for _, route := range routes {
+		records = append(records, NewTransitRoute(route))
+	}
+
+	if format == FormatCSV {
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, record.csvRecord())
+		}
+		return writeCSV(w, transitRouteCSVHeader, rows)
+	}
+	return writeJSON(w, format, records)
+}
+
+// WriteCacheEntries writes cache entry info to w in a machine-readable format
+func WriteCacheEntries(w io.Writer, format Format, entries []cache.EntryInfo) error {
+	records := make([]CacheEntry, 0, len(entries))
+	for _, entry := range entries {
+		records = append(records, NewCacheEntry(entry))
+	}
+
+	if format == FormatCSV {
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, record.csvRecord())
+		}
+		return writeCSV(w, cacheEntryCSVHeader, rows)
+	}
+	return writeJSON(w, format, records)
+}
+
+// WriteStations writes stations to w in a machine-readable format
+func WriteStations(w io.Writer, format Format, found []stations.Station) error {
+	records := make([]Station, 0, len(found))
+	for _, station := range found {
+		records = append(records, Station{Code: station.Code, Name: station.Name})
+	}
+
+	if format == FormatCSV {
+		rows := make([][]string, 0, len(records))
+		for _, record := range records {
+			rows = append(rows, []string{record.Code, record.Name})
+		}
+		return writeCSV(w, []string{"code", "name"}, rows)
+	}
+	return writeJSON(w, format, records)
+}
+
+// writeCSV writes a header and rows as CSV
+func writeCSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV rows: %w", err)
+	}
+	return nil
+}
+
+// writeJSON writes records as a JSON array or as newline-delimited JSON
+func writeJSON[T any](w io.Writer, format Format, records []T) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(records); err != nil {
+			return fmt.Errorf("failed to write JSON: %w", err)
+		}
+		return nil
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("failed to write NDJSON: %w", err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported output format '%s'", format)
+}
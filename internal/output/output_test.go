@@ -0,0 +1,96 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"trains/internal/types"
+)
+
+func testConnection() types.RouteConnection {
+	return types.RouteConnection{
+		Train1: types.TrainData{Number: "11089", Name: "BGKT PUNE EXPRESS", SourceStationCode: "BL",
+			SourceTime: "01:08", DestStationCode: "KYN", DestTime: "04:42", RunningDays: "0001000"},
+		Train2: types.TrainData{Number: "17617", Name: "TAPOVAN EXPRESS", SourceStationCode: "KYN",
+			SourceTime: "06:27", DestStationCode: "NED", DestTime: "18:00", RunningDays: "1111111"},
+		TotalTime:      "16h 52m",
+		Connection:     "Same day - 1h 45m layover (Wed)",
+		LayoverMinutes: 105,
+		TotalMinutes:   1012,
+		RunningDays:    types.ParseDayMask("0001000"),
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Format
+		expectError bool
+	}{
+		{input: "text", expected: FormatText},
+		{input: "JSON", expected: FormatJSON},
+		{input: " csv ", expected: FormatCSV},
+		{input: "ndjson", expected: FormatNDJSON},
+		{input: "xml", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseFormat(tt.input)
+			if tt.expectError != (err != nil) {
+				t.Fatalf("ParseFormat(%q) error = %v, expectError %t", tt.input, err, tt.expectError)
+			}
+			if result != tt.expected {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteConnectionsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	connections := []types.RouteConnection{testConnection(), testConnection()}
+	if err := WriteConnections(&buf, FormatNDJSON, connections); err != nil {
+		t.Fatalf("WriteConnections() unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("WriteConnections() wrote %d lines, want 2", len(lines))
+	}
+
+	var record Connection
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+		t.Fatalf("failed to decode NDJSON line: %v", err)
+	}
+	if record.LayoverMinutes != 105 || record.TotalMinutes != 1012 {
+		t.Errorf("record minutes = (%d, %d), want (105, 1012)", record.LayoverMinutes, record.TotalMinutes)
+	}
+	if record.CommonDaysMask != 8 {
+		t.Errorf("record.CommonDaysMask = %d, want 8", record.CommonDaysMask)
+	}
+}
+
+func TestWriteTransitRoutesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	routes := []types.TransitRoute{{
+		SourceStation: "VALSAD", SourceStationCode: "BL", SourceTrainCount: 15,
+		TransitStation: "KALYAN JN", TransitStationCode: "KYN", TransitTrainCount: 4,
+		DestStation: "H SAHIB NANDED", DestStationCode: "NED", Distance: "754 Kms",
+		ShowLink: "/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN",
+	}}
+	if err := WriteTransitRoutes(&buf, FormatCSV, routes); err != nil {
+		t.Fatalf("WriteTransitRoutes() unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("WriteTransitRoutes() wrote %d lines, want 2", len(lines))
+	}
+	expected := "VALSAD,BL,15,KALYAN JN,KYN,4,H SAHIB NANDED,NED,19,754,https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN"
+	if lines[1] != expected {
+		t.Errorf("CSV row = %q, want %q", lines[1], expected)
+	}
+}
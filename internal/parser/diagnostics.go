@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reasons a row is skipped by a parser
+const (
+	ReasonTooFewCells     = "too few cells"
+	ReasonInvalidStation  = "invalid station"
+	ReasonInvalidCount    = "invalid train count"
+	ReasonMissingLink     = "missing details link"
+	ReasonInvalidDistance = "invalid distance"
+	ReasonInvalidJSON     = "invalid JSON"
+	ReasonMissingField    = "missing field"
+	ReasonInvalidField    = "invalid field"
+)
+
+// RowError describes why one row of a page could not be parsed
+type RowError struct {
+	Row    int    `json:"row"` // 1-based index among the candidate rows or objects of the page
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+// Error implements error
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %s: %s", e.Row, e.Reason, e.Detail)
+}
+
+// Diagnostics reports how much of a page a parser understood
+type Diagnostics struct {
+	RowsSeen   int        `json:"rows_seen"`   // Candidate rows found on the page
+	RowsParsed int        `json:"rows_parsed"` // Rows turned into results
+	Errors     []RowError `json:"errors,omitempty"`
+}
+
+// skip records that a row was skipped
+func (d *Diagnostics) skip(row int, reason, detail string) {
+	d.Errors = append(d.Errors, RowError{Row: row, Reason: reason, Detail: detail})
+}
+
+// RowsSkipped returns how many candidate rows did not produce a result
+func (d Diagnostics) RowsSkipped() int {
+	return len(d.Errors)
+}
+
+// SkipReasons counts skipped rows by reason
+func (d Diagnostics) SkipReasons() map[string]int {
+	reasons := make(map[string]int)
+	for _, rowErr := range d.Errors {
+		reasons[rowErr.Reason]++
+	}
+	return reasons
+}
+
+// Merge adds the counts and errors of other, e.g. to total several pages
+func (d *Diagnostics) Merge(other Diagnostics) {
+	d.RowsSeen += other.RowsSeen
+	d.RowsParsed += other.RowsParsed
+	d.Errors = append(d.Errors, other.Errors...)
+}
+
+// String summarizes the diagnostics, e.g. "parsed 40 of 42 rows (skipped: 2 invalid station)"
+func (d Diagnostics) String() string {
+	summary := fmt.Sprintf("parsed %d of %d rows", d.RowsParsed, d.RowsSeen)
+	if d.RowsSkipped() == 0 {
+		return summary
+	}
+
+	reasons := d.SkipReasons()
+	names := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		names = append(names, reason)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, reason := range names {
+		parts = append(parts, fmt.Sprintf("%d %s", reasons[reason], reason))
+	}
+	return fmt.Sprintf("%s (skipped: %s)", summary, strings.Join(parts, ", "))
+}
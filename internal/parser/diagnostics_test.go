@@ -0,0 +1,15 @@
+package parser
+
+import "testing"
+
+func TestDiagnosticsString(t *testing.T) {
+	diagnostics := Diagnostics{RowsSeen: 5, RowsParsed: 2}
+	diagnostics.skip(1, ReasonInvalidStation, "x")
+	diagnostics.skip(2, ReasonInvalidStation, "y")
+	diagnostics.skip(3, ReasonInvalidCount, "z")
+
+	want := "parsed 2 of 5 rows (skipped: 2 invalid station, 1 invalid train count)"
+	if got := diagnostics.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
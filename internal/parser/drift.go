@@ -0,0 +1,243 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrFormatChanged is wrapped by errors caused by etrain.info changing its markup
+var ErrFormatChanged = errors.New("upstream format changed")
+
+// maxSnippetLength bounds the markup quoted by a Drift
+const maxSnippetLength = 400
+
+// Page kinds checked for drift
+const (
+	PageTransit = "transit"
+	PageTrains  = "trains"
+)
+
+// transitHeaders are the column headings expected in the transit table, in order
+var transitHeaders = []string{"From", "Trains", "Via", "Trains", "To", "Distance"}
+
+// knownTrainDataKeys are the data-train keys the parser understands or deliberately ignores
+var knownTrainDataKeys = map[string]bool{
+	"typ": true, "num": true, "name": true, "s": true, "st": true, "d": true, "dt": true,
+	"tt": true, "dy": true, "book": true, "arp": true,
+}
+
+func init() {
+	for _, aliases := range trainDataAliases {
+		for _, key := range aliases {
+			knownTrainDataKeys[key] = true
+		}
+	}
+}
+
+// Drift is one sign that a page no longer has the structure the parsers expect
+type Drift struct {
+	Problem string `json:"problem"`
+	Snippet string `json:"snippet,omitempty"` // Offending markup, shortened
+	Fatal   bool   `json:"fatal"`             // Results from the page cannot be trusted
+}
+
+// DriftError reports the fatal drift found on a page
+type DriftError struct {
+	Page   string // PageTransit or PageTrains
+	Drifts []Drift
+}
+
+// Error implements error, quoting the offending snippets on their own lines
+func (e *DriftError) Error() string {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%v on %s page", ErrFormatChanged, e.Page)
+	for _, drift := range e.Drifts {
+		fmt.Fprintf(&msg, "\n  - %s", drift.Problem)
+		if drift.Snippet != "" {
+			fmt.Fprintf(&msg, "\n    %s", drift.Snippet)
+		}
+	}
+	return msg.String()
+}
+
+// Unwrap returns ErrFormatChanged
+func (e *DriftError) Unwrap() error {
+	return ErrFormatChanged
+}
+
+// DriftErr returns a *DriftError for the fatal drifts of a page, or nil if there are none
+func DriftErr(page string, drifts []Drift) error {
+	var fatal []Drift
+	for _, drift := range drifts {
+		if drift.Fatal {
+			fatal = append(fatal, drift)
+		}
+	}
+	if len(fatal) == 0 {
+		return nil
+	}
+	return &DriftError{Page: page, Drifts: fatal}
+}
+
+// CheckTransitPage looks for structural changes of a transit listing page. A page with the
+// table header but no routes is a valid empty result; a page without either is not.
+func CheckTransitPage(content string) []Drift {
+	doc := parseHTML(content)
+
+	var header *html.Node
+	var rows []*html.Node
+	for _, row := range findAll(doc, "tr") {
+		if header == nil && len(childElements(row, "th")) > 0 && strings.Contains(text(row), "Via") {
+			header = row
+		}
+		if len(childElements(row, "td")) > 0 && isTransitRow(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	var drifts []Drift
+	if header == nil {
+		drifts = append(drifts, Drift{
+			Problem: "transit table header not found",
+			Snippet: snippet(bodyOf(doc)),
+			Fatal:   len(rows) == 0,
+		})
+	} else if headings := cellTexts(childElements(header, "th")); strings.Join(headings, "|") != strings.Join(transitHeaders, "|") {
+		drifts = append(drifts, Drift{
+			Problem: fmt.Sprintf("transit table columns are %q, want %q", headings, transitHeaders),
+			Snippet: snippet(header),
+			Fatal:   len(headings) != len(transitHeaders), // Renamed columns alone do not move cells
+		})
+	}
+
+	var badRows []*html.Node
+	for _, row := range rows {
+		if len(childElements(row, "td")) != transitRowCells {
+			badRows = append(badRows, row)
+		}
+	}
+	if len(badRows) > 0 {
+		drifts = append(drifts, Drift{
+			Problem: fmt.Sprintf("%d of %d transit rows have %d cells, want %d",
+				len(badRows), len(rows), len(childElements(badRows[0], "td")), transitRowCells),
+			Snippet: snippet(badRows[0]),
+			Fatal:   len(badRows) == len(rows),
+		})
+	}
+
+	if len(rows) > 0 {
+		if _, diagnostics := ParseTransitRoutes(content); diagnostics.RowsParsed == 0 {
+			drifts = append(drifts, Drift{
+				Problem: fmt.Sprintf("no transit row could be parsed: %v", diagnostics),
+				Snippet: snippet(rows[0]),
+				Fatal:   true,
+			})
+		}
+	}
+	return drifts
+}
+
+// CheckTrainPage looks for structural changes of a via-search page: train rows without
+// data-train attributes, and data-train keys the parser does not know
+func CheckTrainPage(content string) []Drift {
+	doc := parseHTML(content)
+
+	objects := findAttr(doc, "data-train")
+	if len(objects) == 0 {
+		// Without train data the listing table must be empty too, or its rows lost their attribute
+		for _, table := range findAll(doc, "table") {
+			if class, _ := attr(table, "class"); !strings.Contains(class, "trainlist") {
+				continue
+			}
+			for _, row := range findAll(table, "tr") {
+				if len(childElements(row, "td")) > 0 {
+					return []Drift{{
+						Problem: "train rows have no data-train attribute",
+						Snippet: snippet(row),
+						Fatal:   true,
+					}}
+				}
+			}
+			return nil
+		}
+		return []Drift{{
+			Problem: "neither data-train attributes nor a train list table found",
+			Snippet: snippet(bodyOf(doc)),
+			Fatal:   true,
+		}}
+	}
+
+	var drifts []Drift
+	unknown := make(map[string]string) // Unknown key -> first object using it
+	for _, data := range objects {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal([]byte(data), &fields) != nil {
+			continue // Reported by ParseTrainData
+		}
+		for key := range fields {
+			if _, seen := unknown[key]; !knownTrainDataKeys[key] && !seen {
+				unknown[key] = data
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		keys := make([]string, 0, len(unknown))
+		for key := range unknown {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		drifts = append(drifts, Drift{
+			Problem: fmt.Sprintf("unknown data-train keys: %s", strings.Join(keys, ", ")),
+			Snippet: compactJSON(unknown[keys[0]]),
+		})
+	}
+
+	if _, diagnostics, err := ParseTrainData(content); err != nil {
+		drifts = append(drifts, Drift{
+			Problem: fmt.Sprintf("train data could not be decoded: %v", diagnostics),
+			Snippet: diagnostics.Errors[0].Detail,
+			Fatal:   true,
+		})
+	}
+	return drifts
+}
+
+// cellTexts returns the text of each cell
+func cellTexts(cells []*html.Node) []string {
+	texts := make([]string, 0, len(cells))
+	for _, cell := range cells {
+		texts = append(texts, text(cell))
+	}
+	return texts
+}
+
+// bodyOf returns the body element of a document, or the document itself
+func bodyOf(doc *html.Node) *html.Node {
+	if bodies := findAll(doc, "body"); len(bodies) > 0 {
+		return bodies[0]
+	}
+	return doc
+}
+
+// snippet renders node as markup with whitespace collapsed, shortened for messages
+func snippet(node *html.Node) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, node); err != nil {
+		return ""
+	}
+	return shorten(strings.Join(strings.Fields(buf.String()), " "), maxSnippetLength)
+}
+
+// shorten cuts s to at most max bytes, marking the cut with "..." and keeping runes whole
+func shorten(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max-3], "") + "..."
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckTransitPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		problem   string // Expected in the first drift, empty for none
+		wantFatal bool
+	}{
+		{
+			name: "Expected format",
+			content: `<table><tr><th>From</th><th>Trains</th><th>Via</th><th>Trains</th><th>To</th><th>Distance</th></tr>
+<tr><td>VALSAD<br>(BL)</td><td>15</td><td><a href="/trains/BL-to-NED-via-KYN">Show</a> KALYAN JN<br>(KYN)</td><td>4</td><td>H SAHIB NANDED<br>(NED)</td><td>754 Kms</td></tr></table>`,
+		},
+		{
+			name:    "Some rows broken",
+			content: transitTableHTML,
+			problem: "1 of 6 transit rows have 2 cells",
+		},
+		{
+			name:    "No routes",
+			content: `<table><tr><th>From</th><th>Trains</th><th>Via</th><th>Trains</th><th>To</th><th>Distance</th></tr></table>`,
+		},
+		{
+			name:      "Table replaced",
+			content:   `<html><body><div class="transit-cards"><span>KALYAN JN</span></div></body></html>`,
+			problem:   "transit table header not found",
+			wantFatal: true,
+		},
+		{
+			name: "Column added",
+			content: `<table><tr><th>From</th><th>Trains</th><th>Via</th><th>Trains</th><th>To</th><th>Time</th><th>Distance</th></tr>
+<tr><td>VALSAD<br>(BL)</td><td>15</td><td><a href="/trains/BL-to-NED-via-KYN">Show</a> KALYAN JN<br>(KYN)</td><td>4</td><td>H SAHIB NANDED<br>(NED)</td><td>18h</td><td>754 Kms</td></tr></table>`,
+			problem:   "transit table columns",
+			wantFatal: true,
+		},
+		{
+			name: "Column renamed",
+			content: `<table><tr><th>From</th><th>Trains</th><th>Via</th><th>Trains</th><th>To</th><th>Kms</th></tr>
+<tr><td>VALSAD<br>(BL)</td><td>15</td><td><a href="/trains/BL-to-NED-via-KYN">Show</a> KALYAN JN<br>(KYN)</td><td>4</td><td>H SAHIB NANDED<br>(NED)</td><td>754 Kms</td></tr></table>`,
+			problem: "transit table columns",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drifts := CheckTransitPage(tt.content)
+
+			if tt.problem == "" {
+				if len(drifts) != 0 {
+					t.Fatalf("CheckTransitPage() = %+v, want no drift", drifts)
+				}
+				return
+			}
+			if len(drifts) == 0 || !strings.Contains(drifts[0].Problem, tt.problem) {
+				t.Fatalf("CheckTransitPage() = %+v, want %q", drifts, tt.problem)
+			}
+			if drifts[0].Snippet == "" {
+				t.Error("drift has no snippet")
+			}
+
+			err := DriftErr(PageTransit, drifts)
+			if tt.wantFatal != errors.Is(err, ErrFormatChanged) {
+				t.Errorf("DriftErr() = %v, want fatal %t", err, tt.wantFatal)
+			}
+		})
+	}
+}
+
+func TestCheckTrainPage(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		problem   string // Expected in the first drift, empty for none
+		wantFatal bool
+	}{
+		{
+			name:    "Expected format",
+			content: `<table class="trainlist"><tr data-train='{"num":"11089","s":"BL","st":"01:08","d":"KYN","dt":"04:42","cls":"SL"}'><td>11089</td></tr></table>`,
+		},
+		{
+			name:    "No trains",
+			content: `<table class="trainlist"><tr><th>Train</th></tr></table>`,
+		},
+		{
+			name:      "Attribute renamed",
+			content:   `<table class="trainlist"><tr data-trn='{"num":"11089"}'><td>11089</td></tr></table>`,
+			problem:   "train rows have no data-train attribute",
+			wantFatal: true,
+		},
+		{
+			name:      "Markup replaced",
+			content:   `<html><body><ul class="trains"><li>11089</li></ul></body></html>`,
+			problem:   "neither data-train attributes nor a train list table found",
+			wantFatal: true,
+		},
+		{
+			name:    "Unknown keys",
+			content: `<table class="trainlist"><tr data-train='{"num":"11089","s":"BL","st":"01:08","d":"KYN","dt":"04:42","zone":"WR","rk":1}'><td>11089</td></tr></table>`,
+			problem: "unknown data-train keys: rk, zone",
+		},
+		{
+			name:      "Keys renamed",
+			content:   `<table class="trainlist"><tr data-train='{"number":"11089","from":"BL"}'><td>11089</td></tr></table>`,
+			problem:   "unknown data-train keys",
+			wantFatal: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drifts := CheckTrainPage(tt.content)
+
+			if tt.problem == "" {
+				if len(drifts) != 0 {
+					t.Fatalf("CheckTrainPage() = %+v, want no drift", drifts)
+				}
+				return
+			}
+			if len(drifts) == 0 || !strings.Contains(drifts[0].Problem, tt.problem) {
+				t.Fatalf("CheckTrainPage() = %+v, want %q", drifts, tt.problem)
+			}
+
+			err := DriftErr(PageTrains, drifts)
+			if tt.wantFatal != errors.Is(err, ErrFormatChanged) {
+				t.Errorf("DriftErr() = %v, want fatal %t", err, tt.wantFatal)
+			}
+		})
+	}
+}
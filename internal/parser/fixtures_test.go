@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"trains/internal/fixtures"
+)
+
+// TestParseRecordedPages guards the parsers against regressions using a recorded fixture bundle
+func TestParseRecordedPages(t *testing.T) {
+	pages, err := fixtures.Load(filepath.Join("testdata", "bl-ned"))
+	if err != nil {
+		t.Fatalf("failed to load fixture bundle: %v", err)
+	}
+
+	trains, _, err := ParseTrainData(pages["https://etrain.info/trains/BL-to-NED-via-KYN"])
+	if err != nil {
+		t.Fatalf("ParseTrainData() error: %v", err)
+	}
+	if len(trains) != 4 {
+		t.Errorf("ParseTrainData() found %d trains, want 4", len(trains))
+	}
+
+	routes, _ := ParseTransitRoutes(pages["https://etrain.info/transit/BL-NED?page=1"])
+	if len(routes) != 3 {
+		t.Fatalf("ParseTransitRoutes() found %d routes, want 3", len(routes))
+	}
+	if routes[0].TransitStation != "KALYAN JN" || routes[0].TransitStationCode != "KYN" {
+		t.Errorf("first route via %s (%s), want KALYAN JN (KYN)", routes[0].TransitStation, routes[0].TransitStationCode)
+	}
+}
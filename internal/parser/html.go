@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseHTML parses a page into a DOM; the HTML5 parser accepts any input, fixing up broken markup
+func parseHTML(content string) *html.Node {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		// html.Parse only fails on reader errors, which a strings.Reader never returns
+		return &html.Node{Type: html.DocumentNode}
+	}
+	return doc
+}
+
+// findAll returns every element named tag below node, in document order
+func findAll(node *html.Node, tag string) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == tag {
+			found = append(found, n)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return found
+}
+
+// childElements returns the direct children of node named tag
+func childElements(node *html.Node, tag string) []*html.Node {
+	var children []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == tag {
+			children = append(children, child)
+		}
+	}
+	return children
+}
+
+// attr returns the value of the named attribute of node
+func attr(node *html.Node, name string) (string, bool) {
+	for _, a := range node.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// textLines returns the text of node split at <br> elements, with whitespace collapsed.
+// Text inside elements named in skip (e.g. "a") is left out.
+func textLines(node *html.Node, skip ...string) []string {
+	var lines []string
+	var current strings.Builder
+
+	flush := func() {
+		if line := strings.Join(strings.Fields(current.String()), " "); line != "" {
+			lines = append(lines, line)
+		}
+		current.Reset()
+	}
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			current.WriteString(n.Data)
+			current.WriteString(" ")
+			return
+		case html.ElementNode:
+			if n.Data == "br" {
+				flush()
+				return
+			}
+			for _, tag := range skip {
+				if n.Data == tag {
+					return
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	flush()
+
+	return lines
+}
+
+// text returns the whitespace-collapsed text of node
+func text(node *html.Node) string {
+	return strings.Join(textLines(node), " ")
+}
+
+// findAttr returns the values of the named attribute on every element below node, in document order
+func findAttr(node *html.Node, name string) []string {
+	var values []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if value, found := attr(n, name); found {
+				values = append(values, value)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return values
+}
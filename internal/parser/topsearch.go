@@ -6,103 +6,133 @@ import (
 	"strconv"
 	"strings"
 
+	"golang.org/x/net/html"
+
 	"trains/internal/types"
 )
 
-// ParseTransitRoutes extracts transit routes from HTML
-func ParseTransitRoutes(htmlContent string) []types.TransitRoute {
+// stationCellPattern matches a station cell's text such as "KALYAN JN (KYN)"; names may hold any
+// characters, but codes must be valid station codes, since routes through other codes cannot be queried
+var stationCellPattern = regexp.MustCompile(`^(.*\S)\s*\((` + stationCode + `)\)$`)
+
+// transitRowCells is the number of cells in a transit table row:
+// source | source count | show link + transit | transit count | destination | distance
+const transitRowCells = 6
+
+// ParseTransitRoutes extracts transit routes from the transit table of a page, reporting every
+// row that looks like a route but could not be parsed
+func ParseTransitRoutes(htmlContent string) ([]types.TransitRoute, Diagnostics) {
 	var routes []types.TransitRoute
+	var diagnostics Diagnostics
 	
-	// Look for table rows containing route data with multiline matching
-	// The pattern matches table rows with station data
-	rowPattern := regexp.MustCompile(`(?s)<tr[^>]*>.*?</tr>`)
-	rows := rowPattern.FindAllString(htmlContent, -1)
-	
-	fmt.Printf("Found %d table rows to parse\n", len(rows))
-	
-	for i, row := range rows {
-		// Skip header rows or rows without proper data
-		if !strings.Contains(row, "Show") || !strings.Contains(row, "Kms") {
-			continue
-		}
+	for _, row := range findAll(parseHTML(htmlContent), "tr") {
+		cells := childElements(row, "td")
 		
-		// Extract table cells
-		cellPattern := regexp.MustCompile(`(?s)<td[^>]*>(.*?)</td>`)
-		cellMatches := cellPattern.FindAllStringSubmatch(row, -1)
-		
-		if len(cellMatches) < 6 { // Need at least 6 cells: source, source_count, transit, transit_count, dest, distance
-			fmt.Printf("Row %d: Found only %d cells, skipping\n", i, len(cellMatches))
-			continue
-		}
-		
-		// Structure: source | source_count | show_link+transit | transit_count | dest | distance
-		// Extract source station info (cell 0)
-		sourceStationPattern := regexp.MustCompile(`([A-Z\s]+)\s*<br>\s*\(([A-Z]+)\)`)
-		sourceMatch := sourceStationPattern.FindStringSubmatch(cellMatches[0][1])
-		if len(sourceMatch) < 3 {
+		// Header rows have no data cells; other rows are routes if they link to a train listing or give a distance
+		if len(cells) == 0 || !isTransitRow(row) {
 			continue
 		}
+		diagnostics.RowsSeen++
+		rowNum := diagnostics.RowsSeen
 		
-		// Extract source train count (cell 1)
-		sourceCountStr := strings.TrimSpace(cellMatches[1][1])
-		sourceCount, err := strconv.Atoi(sourceCountStr)
-		if err != nil {
+		if len(cells) < transitRowCells {
+			diagnostics.skip(rowNum, ReasonTooFewCells, fmt.Sprintf("found %d cells, want %d", len(cells), transitRowCells))
 			continue
 		}
 		
-		// Extract Show link and transit station from cell 2
-		linkPattern := regexp.MustCompile(`href="([^"]*)"`)
-		linkMatch := linkPattern.FindStringSubmatch(cellMatches[2][1])
-		if len(linkMatch) < 2 {
+		route, reason, detail := parseTransitRow(cells)
+		if reason != "" {
+			diagnostics.skip(rowNum, reason, detail)
 			continue
 		}
 		
-		// Extract transit station from the same cell (after the Show link)
-		transitMatch := sourceStationPattern.FindStringSubmatch(cellMatches[2][1])
-		if len(transitMatch) < 3 {
-			continue
-		}
-		
-		// Extract transit train count (cell 3)
-		transitCountStr := strings.TrimSpace(cellMatches[3][1])
-		transitCount, err := strconv.Atoi(transitCountStr)
-		if err != nil {
-			continue
-		}
-		
-		// Extract destination station info (cell 4)
-		destMatch := sourceStationPattern.FindStringSubmatch(cellMatches[4][1])
-		if len(destMatch) < 3 {
-			continue
-		}
-		
-		// Extract distance (cell 5)
-		distanceStr := strings.TrimSpace(cellMatches[5][1])
-		
-		// Build the route
-		route := types.TransitRoute{
-			SourceStation:      strings.TrimSpace(sourceMatch[1]),
-			SourceStationCode:  sourceMatch[2],
-			SourceTrainCount:   sourceCount,
-			TransitStation:     strings.TrimSpace(transitMatch[1]),
-			TransitStationCode: transitMatch[2],
-			TransitTrainCount:  transitCount,
-			DestStation:        strings.TrimSpace(destMatch[1]),
-			DestStationCode:    destMatch[2],
-			Distance:           distanceStr,
-			ShowLink:           linkMatch[1],
-		}
-		
 		routes = append(routes, route)
-		
-		// Debug first 3 routes
-		if len(routes) <= 3 {
-			fmt.Printf("Route %d: %s (%s) [%d] → %s (%s) [%d] → %s (%s) - %s\n",
-				len(routes), route.SourceStation, route.SourceStationCode, route.SourceTrainCount,
-				route.TransitStation, route.TransitStationCode, route.TransitTrainCount,
-				route.DestStation, route.DestStationCode, route.Distance)
+		diagnostics.RowsParsed++
+	}
+	
+	return routes, diagnostics
+}
+
+// isTransitRow reports whether a table row looks like a transit route
+func isTransitRow(row *html.Node) bool {
+	for _, link := range findAll(row, "a") {
+		if href, _ := attr(link, "href"); strings.Contains(href, "/trains/") {
+			return true
 		}
 	}
+	return strings.Contains(text(row), "Kms")
+}
+
+// parseTransitRow parses the cells of one route row, returning a skip reason and detail on failure
+func parseTransitRow(cells []*html.Node) (types.TransitRoute, string, string) {
+	var route types.TransitRoute
+	var ok bool
+	
+	if route.SourceStation, route.SourceStationCode, ok = parseStationCell(cells[0]); !ok {
+		return route, ReasonInvalidStation, fmt.Sprintf("source %q", text(cells[0]))
+	}
+	
+	count, err := strconv.Atoi(text(cells[1]))
+	if err != nil {
+		return route, ReasonInvalidCount, fmt.Sprintf("source count %q", text(cells[1]))
+	}
+	route.SourceTrainCount = count
+	
+	// The transit cell holds the Show link followed by the station
+	for _, link := range findAll(cells[2], "a") {
+		if href, found := attr(link, "href"); found && href != "" {
+			route.ShowLink = href
+			break
+		}
+	}
+	if route.ShowLink == "" {
+		return route, ReasonMissingLink, fmt.Sprintf("transit cell %q", text(cells[2]))
+	}
+	if route.TransitStation, route.TransitStationCode, ok = parseStationCell(cells[2], "a"); !ok {
+		return route, ReasonInvalidStation, fmt.Sprintf("transit %q", text(cells[2]))
+	}
+	
+	count, err = strconv.Atoi(text(cells[3]))
+	if err != nil {
+		return route, ReasonInvalidCount, fmt.Sprintf("transit count %q", text(cells[3]))
+	}
+	route.TransitTrainCount = count
+	
+	if route.DestStation, route.DestStationCode, ok = parseStationCell(cells[4]); !ok {
+		return route, ReasonInvalidStation, fmt.Sprintf("destination %q", text(cells[4]))
+	}
+	
+	route.Distance = text(cells[5])
+	if ParseDistanceKm(route.Distance) == 0 {
+		return route, ReasonInvalidDistance, fmt.Sprintf("distance %q", route.Distance)
+	}
+	
+	return route, "", ""
+}
+
+// parseStationCell extracts the station name and code from a cell such as "KALYAN JN<br>(KYN)",
+// leaving out the text of elements named in skip
+func parseStationCell(cell *html.Node, skip ...string) (string, string, bool) {
+	match := stationCellPattern.FindStringSubmatch(strings.Join(textLines(cell, skip...), " "))
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// BuildTransitURL builds the etrain.info transit listing URL for the given station codes
+func BuildTransitURL(sourceStation, destinationStation string) (string, error) {
+	source, err := ValidateStationCode(sourceStation)
+	if err != nil {
+		return "", err
+	}
+	destination, err := ValidateStationCode(destinationStation)
+	if err != nil {
+		return "", err
+	}
+	if source == destination {
+		return "", fmt.Errorf("source and destination stations must differ (got %s)", source)
+	}
 	
-	return routes
-}
\ No newline at end of file
+	return fmt.Sprintf("%s/transit/%s-%s", EtrainBaseURL, source, destination), nil
+}
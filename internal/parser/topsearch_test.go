@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"testing"
+)
+
+const transitTableHTML = `<table>
+<tr><th>From</th><th>Trains</th><th>Via</th><th>Trains</th><th>To</th><th>Distance</th></tr>
+<tr><td>VALSAD<br>(BL)</td><td>15</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN">Show</a> KALYAN JN<br>(KYN)</td><td>4</td><td>H SAHIB NANDED<br>(NED)</td><td>754 Kms</td></tr>
+<tr><td>VALSAD<br>(BL)</td><td>7</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-C-Shivaji-Mah-T-CSMT">Show</a> C. SHIVAJI MAH T<br>(CSMT)</td><td>3</td><td>H.Sahib Nanded<br>(NED)</td><td>812 Kms</td></tr>
+<tr><td>VALSAD (BL)</td><td>2</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Pune-Jn-PUNE">Show</a> Pune Jn 2<br>(PUNE)</td><td>1</td><td>H SAHIB NANDED<br>(NED)</td><td>950 Kms</td></tr>
+<tr><td>VALSAD<br>(BL)</td><td>many</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Manmad-Jn-MMR">Show</a> MANMAD JN<br>(MMR)</td><td>8</td><td>H SAHIB NANDED<br>(NED)</td><td>800 Kms</td></tr>
+<tr><td>VALSAD<br>(BL)</td><td><a href="/trains/broken">Show</a></td></tr>
+<tr><td>VALSAD<br>BL</td><td>5</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Surat-ST">Show</a> SURAT<br>(ST)</td><td>5</td><td>H SAHIB NANDED<br>(NED)</td><td>900 Kms</td></tr>
+<tr><td colspan="6">Page 1 of 3</td></tr>
+</table>`
+
+func TestParseTransitRoutes(t *testing.T) {
+	routes, diagnostics := ParseTransitRoutes(transitTableHTML)
+
+	wantStations := []struct{ name, code string }{
+		{"KALYAN JN", "KYN"},
+		{"C. SHIVAJI MAH T", "CSMT"},
+		{"Pune Jn 2", "PUNE"},
+	}
+	if len(routes) != len(wantStations) {
+		t.Fatalf("ParseTransitRoutes() found %d routes, want %d: %+v", len(routes), len(wantStations), routes)
+	}
+	for i, want := range wantStations {
+		if routes[i].TransitStation != want.name || routes[i].TransitStationCode != want.code {
+			t.Errorf("route %d transit = %q (%s), want %q (%s)", i, routes[i].TransitStation, routes[i].TransitStationCode, want.name, want.code)
+		}
+	}
+	if routes[1].DestStation != "H.Sahib Nanded" || routes[2].SourceStationCode != "BL" {
+		t.Errorf("unexpected stations in %+v", routes)
+	}
+	if routes[0].ShowLink != "/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN" || routes[0].Distance != "754 Kms" {
+		t.Errorf("route 0 = %+v", routes[0])
+	}
+
+	// The pagination row is neither a route nor counted
+	if diagnostics.RowsSeen != 6 || diagnostics.RowsParsed != 3 || diagnostics.RowsSkipped() != 3 {
+		t.Errorf("diagnostics = %+v, want 6 seen, 3 parsed, 3 skipped", diagnostics)
+	}
+	reasons := diagnostics.SkipReasons()
+	if reasons[ReasonInvalidCount] != 1 || reasons[ReasonTooFewCells] != 1 || reasons[ReasonInvalidStation] != 1 {
+		t.Errorf("skip reasons = %v", reasons)
+	}
+	if diagnostics.Errors[0].Row != 4 {
+		t.Errorf("first error is for row %d, want 4", diagnostics.Errors[0].Row)
+	}
+}
+
+func TestParseTransitRoutesSkipsInvalidCodes(t *testing.T) {
+	// Codes with digits or more than five letters are rejected by ValidateStationCode,
+	// so routes through them could never be searched
+	const table = `<table>
+<tr><td>VALSAD<br>(BL)</td><td>15</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN">Show</a> KALYAN JN<br>(KYN)</td><td>4</td><td>H SAHIB NANDED<br>(NED)</td><td>754 Kms</td></tr>
+<tr><td>VALSAD<br>(BL)</td><td>3</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Halt-HLT2">Show</a> HALT<br>(HLT2)</td><td>1</td><td>H SAHIB NANDED<br>(NED)</td><td>760 Kms</td></tr>
+<tr><td>VALSAD<br>(BL)</td><td>2</td><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Long-LONGER">Show</a> LONG<br>(LONGER)</td><td>1</td><td>H SAHIB NANDED<br>(NED)</td><td>770 Kms</td></tr>
+</table>`
+
+	routes, diagnostics := ParseTransitRoutes(table)
+	if len(routes) != 1 || routes[0].TransitStationCode != "KYN" {
+		t.Fatalf("ParseTransitRoutes() = %+v, want only the route via KYN", routes)
+	}
+	if reasons := diagnostics.SkipReasons(); reasons[ReasonInvalidStation] != 2 {
+		t.Errorf("skip reasons = %v, want 2 invalid stations", reasons)
+	}
+	for _, route := range routes {
+		if _, err := BuildViaSearchURL(route.SourceStationCode, route.DestStationCode, route.TransitStationCode); err != nil {
+			t.Errorf("parsed route cannot be searched: %v", err)
+		}
+	}
+}
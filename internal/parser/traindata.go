@@ -0,0 +1,223 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"trains/internal/types"
+)
+
+// TrainDataError reports train data objects found on a page that could not be decoded.
+// Losing trains silently would hide connections, so this fails the whole page.
+type TrainDataError struct {
+	Diagnostics Diagnostics
+}
+
+// Error implements error
+func (e *TrainDataError) Error() string {
+	details := make([]string, 0, len(e.Diagnostics.Errors))
+	for _, rowErr := range e.Diagnostics.Errors {
+		details = append(details, rowErr.Error())
+	}
+	return fmt.Sprintf("found %d train data objects but parsed %d: %s",
+		e.Diagnostics.RowsSeen, e.Diagnostics.RowsParsed, strings.Join(details, "; "))
+}
+
+// Unwrap returns ErrFormatChanged: the parser no longer understands the page's train data
+func (e *TrainDataError) Unwrap() error {
+	return ErrFormatChanged
+}
+
+// trainDataAliases lists the keys accepted for fields whose name differs between page versions
+var trainDataAliases = map[string][]string{
+	"classes":  {"cls", "classes", "cl"},
+	"pantry":   {"pantry", "pc", "pan"},
+	"distance": {"dist", "distance", "km"},
+}
+
+// decodeTrainData decodes one data-train object, returning a skip reason and detail on failure.
+// Numbers and strings are accepted for each other, unknown and nested fields are ignored.
+func decodeTrainData(data string) (types.TrainData, string, string) {
+	var train types.TrainData
+
+	decoder := json.NewDecoder(strings.NewReader(strings.TrimSpace(data)))
+	decoder.UseNumber()
+
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return train, ReasonInvalidJSON, fmt.Sprintf("%v in %s", err, compactJSON(data))
+	}
+	if fields == nil {
+		return train, ReasonInvalidJSON, fmt.Sprintf("not an object: %s", compactJSON(data))
+	}
+
+	var err error
+	textFields := []struct {
+		key      string
+		target   *string
+		required bool
+	}{
+		{"typ", &train.Type, false},
+		{"num", &train.Number, true},
+		{"name", &train.Name, false},
+		{"s", &train.SourceStationCode, true},
+		{"st", &train.SourceTime, true},
+		{"d", &train.DestStationCode, true},
+		{"dt", &train.DestTime, true},
+		{"tt", &train.TravelTime, false},
+		{"dy", &train.RunningDays, false},
+		{"book", &train.BookingInfo, false},
+	}
+	for _, field := range textFields {
+		value, found := fields[field.key]
+		if !found || value == nil {
+			if field.required {
+				return train, ReasonMissingField, fmt.Sprintf("%q in %s", field.key, compactJSON(data))
+			}
+			continue
+		}
+		if *field.target, err = stringValue(value); err != nil {
+			return train, ReasonInvalidField, fmt.Sprintf("%q: %v", field.key, err)
+		}
+	}
+
+	if value, found := fields["arp"]; found && value != nil {
+		if train.ArrivalPlatform, err = intValue(value); err != nil {
+			return train, ReasonInvalidField, fmt.Sprintf("%q: %v", "arp", err)
+		}
+	}
+
+	if key, value, found := lookupAlias(fields, "classes"); found {
+		if train.Classes, err = listValue(value); err != nil {
+			return train, ReasonInvalidField, fmt.Sprintf("%q: %v", key, err)
+		}
+	}
+	if key, value, found := lookupAlias(fields, "pantry"); found {
+		if train.Pantry, err = boolValue(value); err != nil {
+			return train, ReasonInvalidField, fmt.Sprintf("%q: %v", key, err)
+		}
+	}
+	if key, value, found := lookupAlias(fields, "distance"); found {
+		if train.Distance, err = intValue(value); err != nil {
+			return train, ReasonInvalidField, fmt.Sprintf("%q: %v", key, err)
+		}
+	}
+
+	return train, "", ""
+}
+
+// lookupAlias returns the first present key among the aliases of field
+func lookupAlias(fields map[string]any, field string) (string, any, bool) {
+	for _, key := range trainDataAliases[field] {
+		if value, found := fields[key]; found && value != nil {
+			return key, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// stringValue accepts a string or a number
+func stringValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case json.Number:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("want string, got %s", jsonType(value))
+}
+
+// intValue accepts a number or a numeric string such as "3" or "754 Kms"; empty means 0
+func intValue(value any) (int, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return int(n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %s", v)
+		}
+		return int(f), nil
+	case string:
+		fields := strings.Fields(v)
+		if len(fields) == 0 || fields[0] == "-" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", v)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("want number, got %s", jsonType(value))
+}
+
+// boolValue accepts a boolean, 0/1 or a yes/no style string
+func boolValue(value any) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case json.Number:
+		return v.String() != "0", nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "0", "n", "no", "false":
+			return false, nil
+		case "1", "y", "yes", "true":
+			return true, nil
+		}
+		return false, fmt.Errorf("invalid boolean %q", v)
+	}
+	return false, fmt.Errorf("want boolean, got %s", jsonType(value))
+}
+
+// listValue accepts an array of strings or a string separated by commas or spaces
+func listValue(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' || r == '|' }), nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := stringValue(item)
+			if err != nil {
+				return nil, err
+			}
+			if s != "" {
+				list = append(list, s)
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("want list, got %s", jsonType(value))
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	}
+	return "null"
+}
+
+// compactJSON shortens a data-train value for error messages
+func compactJSON(data string) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, []byte(data)) == nil {
+		data = buf.String()
+	}
+	return shorten(data, 80)
+}
@@ -3,53 +3,105 @@ package parser
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"trains/internal/types"
 )
 
 const (
+	// The layover and journey limits below are the defaults of Constraints; commands may override them
+
 	// Layover time constraints in minutes
 	MinLayoverMinutes = 60  // 1 hour minimum layover
 	MaxLayoverMinutes = 240 // 4 hours maximum layover
-	
+
 	// Journey time constraint in hours
 	MaxJourneyHours = 19
-	
+
 	// Time calculations
 	MinutesPerHour = 60
 	HoursPerDay    = 24
 	MinutesPerDay  = 24 * 60
+
+	// DateLayout is the format of travel dates, e.g. 2026-11-03
+	DateLayout = "2006-01-02"
+
+	// stationCode is the regular expression of a station code, shared by the page parsers and
+	// ValidateStationCode so that every parsed station can be queried
+	stationCode = `[A-Z]{1,5}`
+
+	// EtrainBaseURL is the base URL of the etrain.info website
+	EtrainBaseURL = "https://etrain.info"
+
+	// Version identifies the output of the page parsers; bump it whenever parsing changes
+	// so that cached parse results are invalidated
+	Version = 5
 )
 
+// Constraints holds the limits a connection must satisfy
+type Constraints struct {
+	MinLayoverMinutes int // Minimum wait at the transit station
+	MaxLayoverMinutes int // Maximum wait at the transit station
+	MaxJourneyMinutes int // Total journey time must be under this
+}
+
+// DefaultConstraints returns the 1-4 hour layover window and 19 hour journey limit
+func DefaultConstraints() Constraints {
+	return Constraints{
+		MinLayoverMinutes: MinLayoverMinutes,
+		MaxLayoverMinutes: MaxLayoverMinutes,
+		MaxJourneyMinutes: MaxJourneyHours * MinutesPerHour,
+	}
+}
+
+// Validate checks that the constraints are consistent
+func (c Constraints) Validate() error {
+	if c.MinLayoverMinutes < 0 {
+		return fmt.Errorf("invalid minimum layover %s: must not be negative", FormatMinutes(c.MinLayoverMinutes))
+	}
+	if c.MaxLayoverMinutes < c.MinLayoverMinutes {
+		return fmt.Errorf("invalid maximum layover %s: must not be below the minimum layover %s",
+			FormatMinutes(c.MaxLayoverMinutes), FormatMinutes(c.MinLayoverMinutes))
+	}
+	if c.MaxJourneyMinutes <= 0 {
+		return fmt.Errorf("invalid maximum journey time %s: must be positive", FormatMinutes(c.MaxJourneyMinutes))
+	}
+	return nil
+}
+
 var (
+	// IST is Indian Standard Time, in which every timetable is given
+	IST = time.FixedZone("IST", 5*60*60+30*60)
+
+	// pageLinkPattern matches pagination links like href="/transit/BL-NED?page=3"
+	pageLinkPattern = regexp.MustCompile(`href="[^"]*[?&]page=(\d+)[^"]*"`)
+
+	// travelTimeUnitsPattern matches the parts of travel times like "1d 3h 15m"
+	travelTimeUnitsPattern = regexp.MustCompile(`(\d+)\s*([dhm])\w*`)
+
+	// stationCodePattern matches Indian Railways station codes (e.g. BL, NED, KYN)
+	stationCodePattern = regexp.MustCompile(`^` + stationCode + `$`)
+
 	// dayNormalizationMap maps input day strings to normalized full day names
 	dayNormalizationMap = map[string]string{
 		"sun":       "Sunday",
 		"sunday":    "Sunday",
-		"mon":       "Monday", 
+		"mon":       "Monday",
 		"monday":    "Monday",
 		"tue":       "Tuesday",
-		"tuesday":   "Tuesday", 
+		"tuesday":   "Tuesday",
 		"wed":       "Wednesday",
 		"wednesday": "Wednesday",
 		"thu":       "Thursday",
 		"thursday":  "Thursday",
 		"fri":       "Friday",
 		"friday":    "Friday",
-		"sat":       "Saturday", 
+		"sat":       "Saturday",
 		"saturday":  "Saturday",
 	}
-	
-	// dayAbbreviationMap maps full day names to abbreviations
-	dayAbbreviationMap = map[string]string{
-		"Sunday":    "Sun",
-		"Monday":    "Mon", 
-		"Tuesday":   "Tue",
-		"Wednesday": "Wed",
-		"Thursday":  "Thu",
-		"Friday":    "Fri",
-		"Saturday":  "Sat",
-	}
 )
 
 // ParseTime converts time string to minutes since midnight
@@ -58,12 +110,48 @@ func ParseTime(timeStr string) int {
 	if len(parts) != 2 {
 		return 0
 	}
-	
+
 	hours, _ := strconv.Atoi(parts[0])
 	minutes, _ := strconv.Atoi(parts[1])
 	return hours*MinutesPerHour + minutes
 }
 
+// ParseTravelTime converts a travel time like "03:34", "27:15" or "1d 3h 15m" to minutes
+func ParseTravelTime(travelTime string) (int, bool) {
+	travelTime = strings.TrimSpace(travelTime)
+	if travelTime == "" {
+		return 0, false
+	}
+
+	// Hours may exceed 24 for journeys longer than a day
+	if parts := strings.Split(travelTime, ":"); len(parts) == 2 {
+		hours, hoursErr := strconv.Atoi(strings.TrimSpace(parts[0]))
+		minutes, minutesErr := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if hoursErr != nil || minutesErr != nil || hours < 0 || minutes < 0 || minutes >= MinutesPerHour {
+			return 0, false
+		}
+		return hours*MinutesPerHour + minutes, true
+	}
+
+	matches := travelTimeUnitsPattern.FindAllStringSubmatch(strings.ToLower(travelTime), -1)
+	if len(matches) == 0 || strings.TrimSpace(travelTimeUnitsPattern.ReplaceAllString(strings.ToLower(travelTime), "")) != "" {
+		return 0, false
+	}
+	total := 0
+	for _, match := range matches {
+		value, _ := strconv.Atoi(match[1])
+		switch match[2] {
+		case "d":
+			total += value * MinutesPerDay
+		case "h":
+			total += value * MinutesPerHour
+		case "m":
+			total += value
+		}
+	}
+	return total, true
+}
+
 // ParseDistanceKm extracts distance in kilometers from distance string
 func ParseDistanceKm(distanceStr string) int {
 	// Parse distance string like "754 Kms" or "1038 Kms"
@@ -77,83 +165,131 @@ func ParseDistanceKm(distanceStr string) int {
 	return 0
 }
 
-// GetCommonRunningDays finds common running days between two trains
-func GetCommonRunningDays(days1, days2 string) string {
-	dayNames := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
-	commonDays := make([]string, 0, 7) // Maximum 7 days
-	
-	// Ensure both strings are at least 7 characters
-	if len(days1) < 7 || len(days2) < 7 {
-		return ""
-	}
-	
-	for i := 0; i < 7; i++ {
-		if days1[i] == '1' && days2[i] == '1' {
-			commonDays = append(commonDays, dayNames[i])
-		}
-	}
-	
-	if len(commonDays) == 0 {
-		return ""
-	}
-	
-	return strings.Join(commonDays, ",")
+// RunningDaysMask converts a running days string like "1010101" to a bitmask (bit 0 = Sun, bit 6 = Sat)
+func RunningDaysMask(dayStr string) int {
+	return int(types.ParseDayMask(dayStr))
 }
 
 // FormatRunningDays formats running days string for display
 func FormatRunningDays(dayStr string) string {
-	days := []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
-	runningDays := make([]string, 0, 7) // Maximum 7 days
-	
-	for i, char := range dayStr {
-		if i < len(days) && char == '1' {
-			runningDays = append(runningDays, days[i])
-		}
-	}
-	
-	if len(runningDays) == 7 {
+	return FormatDayMask(types.ParseDayMask(dayStr))
+}
+
+// FormatDayMask formats a set of days for display
+func FormatDayMask(mask types.DayMask) string {
+	if mask == types.AllDays {
 		return "Daily"
 	}
-	return strings.Join(runningDays, ",")
+	return mask.String()
 }
 
-// IsUnder19Hours checks if total time is under 19 hours
-func IsUnder19Hours(timeStr string) bool {
-	// Parse time string like "16h 52m"
-	re := regexp.MustCompile(`(\d+)h\s*(\d+)m`)
-	matches := re.FindStringSubmatch(timeStr)
-	if len(matches) >= 3 {
-		hours, _ := strconv.Atoi(matches[1])
-		return hours < MaxJourneyHours
+// FormatMinutes formats a number of minutes compactly, e.g. "4h", "1h 30m" or "45m"
+func FormatMinutes(minutes int) string {
+	hours, rest := minutes/MinutesPerHour, minutes%MinutesPerHour
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", rest)
+	case rest == 0:
+		return fmt.Sprintf("%dh", hours)
 	}
-	return false
+	return fmt.Sprintf("%dh %dm", hours, rest)
 }
 
 // ValidateAndNormalizeDay validates and normalizes day input
 func ValidateAndNormalizeDay(day string) (normalized string, err error) {
 	day = strings.ToLower(strings.TrimSpace(day))
-	
+
 	if normalized, ok := dayNormalizationMap[day]; ok {
 		return normalized, nil
 	}
-	
+
 	err = fmt.Errorf("invalid day '%s'. Valid options: sun, mon, tue, wed, thu, fri, sat (or full names)", day)
 	return
 }
 
-// GetDayAbbreviation converts full day name to abbreviation
-func GetDayAbbreviation(fullDayName string) string {
-	if abbrev, ok := dayAbbreviationMap[fullDayName]; ok {
-		return abbrev
+// ParseTravelDate parses a travel date given as YYYY-MM-DD, "today" or "tomorrow", the latter
+// two relative to now in IST, and returns midnight of that day in IST
+func ParseTravelDate(input string, now time.Time) (time.Time, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+
+	now = now.In(IST)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, IST)
+	switch input {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	date, err := time.ParseInLocation(DateLayout, input, IST)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%s'. Valid options: YYYY-MM-DD (e.g. 2026-11-03), today, tomorrow", input)
 	}
-	return fullDayName
+	return date, nil
+}
+
+// ParseWeekday converts a full day name like "Wednesday" to a time.Weekday
+func ParseWeekday(fullDayName string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if day.String() == fullDayName {
+			return day, true
+		}
+	}
+	return time.Sunday, false
+}
+
+// DayInputs returns the accepted day inputs in week order, short forms first, each paired
+// with the full day name it stands for
+func DayInputs() [][2]string {
+	inputs := make([][2]string, 0, len(dayNormalizationMap))
+	for input, fullDayName := range dayNormalizationMap {
+		inputs = append(inputs, [2]string{input, fullDayName})
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		dayI, _ := ParseWeekday(inputs[i][1])
+		dayJ, _ := ParseWeekday(inputs[j][1])
+		if dayI != dayJ {
+			return dayI < dayJ
+		}
+		return len(inputs[i][0]) < len(inputs[j][0])
+	})
+	return inputs
 }
 
 // ShouldFetchAllPages determines if URL requires multi-page fetching
 func ShouldFetchAllPages(url string) bool {
 	// Check if URL is a transit URL without page parameter
-	// Examples: 
+	// Examples:
 	// - https://etrain.info/transit/BL-NED (should fetch all pages)
 	// - https://etrain.info/transit/BL-NED?page=1 (single page specified)
 	return strings.Contains(url, "/transit/") && !strings.Contains(url, "page=")
-}
\ No newline at end of file
+}
+
+// ValidateStationCode validates and normalizes a station code
+func ValidateStationCode(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if !stationCodePattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid station code '%s'. Station codes are 1-5 letters (e.g. BL, NED, KYN)", code)
+	}
+	return normalized, nil
+}
+
+// ParseLastPageNumber returns the highest page number linked from the page's pagination, 0 if none
+func ParseLastPageNumber(htmlContent string) int {
+	lastPage := 0
+	for _, match := range pageLinkPattern.FindAllStringSubmatch(htmlContent, -1) {
+		if page, err := strconv.Atoi(match[1]); err == nil && page > lastPage {
+			lastPage = page
+		}
+	}
+	return lastPage
+}
+
+// BuildPageURL returns baseURL with the given page parameter
+func BuildPageURL(baseURL string, page int) string {
+	separator := "?"
+	if strings.Contains(baseURL, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%spage=%d", baseURL, separator, page)
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"testing"
+	"time"
 )
 
 func TestParseTime(t *testing.T) {
@@ -138,15 +139,15 @@ func TestValidateAndNormalizeDay(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result, err := ValidateAndNormalizeDay(tt.input)
-			
+
 			if tt.expectError && err == nil {
 				t.Errorf("ValidateAndNormalizeDay(%q) expected error but got none", tt.input)
 			}
-			
+
 			if !tt.expectError && err != nil {
 				t.Errorf("ValidateAndNormalizeDay(%q) unexpected error: %v", tt.input, err)
 			}
-			
+
 			if result != tt.expected {
 				t.Errorf("ValidateAndNormalizeDay(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
@@ -154,99 +155,194 @@ func TestValidateAndNormalizeDay(t *testing.T) {
 	}
 }
 
-func TestGetCommonRunningDays(t *testing.T) {
+func TestValidateStationCode(t *testing.T) {
 	tests := []struct {
-		name     string
-		days1    string
-		days2    string
-		expected string
+		name        string
+		input       string
+		expected    string
+		expectError bool
 	}{
 		{
-			name:     "All days common",
-			days1:    "1111111",
-			days2:    "1111111",
-			expected: "Sun,Mon,Tue,Wed,Thu,Fri,Sat",
+			name:        "Valid code",
+			input:       "KYN",
+			expected:    "KYN",
+			expectError: false,
 		},
 		{
-			name:     "Some days common",
-			days1:    "1010101",
-			days2:    "1011001",
-			expected: "Sun,Tue,Sat",
+			name:        "Lowercase with spaces",
+			input:       " ned ",
+			expected:    "NED",
+			expectError: false,
 		},
 		{
-			name:     "No days common",
-			days1:    "1010101",
-			days2:    "0101010",
-			expected: "",
+			name:        "Too long",
+			input:       "ABCDEF",
+			expected:    "",
+			expectError: true,
 		},
 		{
-			name:     "Invalid input - short string",
-			days1:    "101",
-			days2:    "1111111",
-			expected: "",
+			name:        "Contains hyphen",
+			input:       "H-NED",
+			expected:    "",
+			expectError: true,
 		},
 		{
-			name:     "Empty strings",
-			days1:    "",
-			days2:    "",
-			expected: "",
+			name:        "Empty string",
+			input:       "",
+			expected:    "",
+			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GetCommonRunningDays(tt.days1, tt.days2)
+			result, err := ValidateStationCode(tt.input)
+
+			if tt.expectError && err == nil {
+				t.Errorf("ValidateStationCode(%q) expected error but got none", tt.input)
+			}
+
+			if !tt.expectError && err != nil {
+				t.Errorf("ValidateStationCode(%q) unexpected error: %v", tt.input, err)
+			}
+
 			if result != tt.expected {
-				t.Errorf("GetCommonRunningDays(%q, %q) = %q, want %q", tt.days1, tt.days2, result, tt.expected)
+				t.Errorf("ValidateStationCode(%q) = %q, want %q", tt.input, result, tt.expected)
 			}
 		})
 	}
 }
 
-func TestIsUnder19Hours(t *testing.T) {
+func TestRunningDaysMask(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		expected bool
+		expected int
 	}{
 		{
-			name:     "Under 19 hours",
-			input:    "16h 52m",
-			expected: true,
+			name:     "Daily",
+			input:    "1111111",
+			expected: 127,
 		},
 		{
-			name:     "Exactly 19 hours",
-			input:    "19h 0m",
-			expected: false,
+			name:     "Sunday only",
+			input:    "1000000",
+			expected: 1,
 		},
 		{
-			name:     "Over 19 hours",
-			input:    "20h 30m",
-			expected: false,
+			name:     "Wednesday and Saturday",
+			input:    "0001001",
+			expected: 8 | 64,
 		},
 		{
-			name:     "Single digit hours",
-			input:    "8h 45m",
-			expected: true,
+			name:     "Empty string",
+			input:    "",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := RunningDaysMask(tt.input)
+			if result != tt.expected {
+				t.Errorf("RunningDaysMask(%q) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseLastPageNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{
+			name:     "Pagination links",
+			input:    `<ul class="pagination"><li><a href="/transit/BL-NED?page=1">1</a></li><li><a href="/transit/BL-NED?page=2">2</a></li><li><a href="/transit/BL-NED?page=6">Last</a></li></ul>`,
+			expected: 6,
 		},
 		{
-			name:     "Invalid format",
-			input:    "invalid",
-			expected: false,
+			name:     "Page parameter after other parameters",
+			input:    `<a href="/transit/BL-NED?sort=dist&page=3">Next</a>`,
+			expected: 3,
 		},
 		{
-			name:     "Empty string",
-			input:    "",
-			expected: false,
+			name:     "No pagination",
+			input:    `<table><tr><td><a href="/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN">Show</a></td></tr></table>`,
+			expected: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := IsUnder19Hours(tt.input)
+			result := ParseLastPageNumber(tt.input)
 			if result != tt.expected {
-				t.Errorf("IsUnder19Hours(%q) = %t, want %t", tt.input, result, tt.expected)
+				t.Errorf("ParseLastPageNumber(%q) = %d, want %d", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTravelTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+		ok       bool
+	}{
+		{name: "Hours and minutes", input: "03:34", expected: 214, ok: true},
+		{name: "More than a day", input: "27:15", expected: 1635, ok: true},
+		{name: "Units", input: "1d 3h 15m", expected: 1635, ok: true},
+		{name: "Hours only", input: "5h", expected: 300, ok: true},
+		{name: "Invalid minutes", input: "03:75", ok: false},
+		{name: "Invalid format", input: "soon", ok: false},
+		{name: "Empty string", input: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := ParseTravelTime(tt.input)
+			if ok != tt.ok || result != tt.expected {
+				t.Errorf("ParseTravelTime(%q) = (%d, %t), want (%d, %t)", tt.input, result, ok, tt.expected, tt.ok)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestParseTravelDate(t *testing.T) {
+	// 01:30 on Tuesday 3 November in India, still the 2nd in UTC
+	now := time.Date(2026, time.November, 2, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{name: "Date", input: "2026-11-05", expected: "2026-11-05"},
+		{name: "Today in IST", input: "today", expected: "2026-11-03"},
+		{name: "Tomorrow", input: " Tomorrow ", expected: "2026-11-04"},
+		{name: "Day name", input: "tuesday", expectError: true},
+		{name: "Invalid date", input: "2026-02-30", expectError: true},
+		{name: "Other layout", input: "03/11/2026", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseTravelDate(tt.input, now)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ParseTravelDate(%q) = %v, expected error", tt.input, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTravelDate(%q) unexpected error: %v", tt.input, err)
+			}
+			if got := result.Format(DateLayout); got != tt.expected || result.Location() != IST || result.Hour() != 0 {
+				t.Errorf("ParseTravelDate(%q) = %v, want midnight of %s in IST", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
@@ -1,96 +1,89 @@
 package parser
 
 import (
-	"encoding/json"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"trains/internal/types"
 )
 
-// ParseTrainData extracts train data from HTML JavaScript objects
-func ParseTrainData(htmlContent string) []types.TrainData {
+// ParseTrainData extracts train data from the data-train attributes of a via-search page.
+// Attribute values are HTML-decoded by the DOM parser, so quoting style and escaped quotes do
+// not matter. If any object found cannot be decoded, a *TrainDataError is returned.
+func ParseTrainData(htmlContent string) ([]types.TrainData, Diagnostics, error) {
 	var trains []types.TrainData
+	var diagnostics Diagnostics
 	
-	// Look for train data in JavaScript objects
-	trainPattern := regexp.MustCompile(`data-train='({[^}]+})'`)
-	matches := trainPattern.FindAllStringSubmatch(htmlContent, -1)
-	
-	fmt.Printf("Found %d train data objects\n", len(matches))
-	
-	for i, match := range matches {
-		if len(match) > 1 {
-			var train types.TrainData
-			err := json.Unmarshal([]byte(match[1]), &train)
-			if err != nil {
-				fmt.Printf("Error parsing train %d: %v\n", i, err)
-				continue
-			}
-			trains = append(trains, train)
-			
-			if i < 5 { // Debug first 5 trains
-				fmt.Printf("Train %d: %s %s from %s(%s) to %s(%s) at %s-%s\n", 
-					i, train.Number, train.Name, train.SourceStationCode, train.SourceTime, train.DestStationCode, train.DestTime, train.SourceTime, train.DestTime)
-			}
+	for i, data := range findAttr(parseHTML(htmlContent), "data-train") {
+		diagnostics.RowsSeen++
+		
+		train, reason, detail := decodeTrainData(data)
+		if reason != "" {
+			diagnostics.skip(i+1, reason, detail)
+			continue
 		}
+		
+		trains = append(trains, train)
+		diagnostics.RowsParsed++
 	}
 	
-	return trains
+	if diagnostics.RowsSkipped() > 0 {
+		return trains, diagnostics, &TrainDataError{Diagnostics: diagnostics}
+	}
+	return trains, diagnostics, nil
 }
 
 // ExtractRouteInfo extracts source, destination, and transit station codes from URL
-func ExtractRouteInfo(url string) (sourceStation, destinationStation, transitStation string) {
-	// URL format: https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN
-	// Extract source, destination, and transit station codes
-	
-	// Default fallback values
-	sourceStation = "BL"
-	destinationStation = "NED" 
-	transitStation = "KYN"
+func ExtractRouteInfo(url string) (sourceStation, destinationStation, transitStation string, err error) {
+	// URL formats:
+	// - https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN
+	// - https://etrain.info/trains/BL-to-NED-via-KYN (as built by BuildViaSearchURL)
+	slug := url[strings.LastIndex(url, "/")+1:]
+	if queryIndex := strings.IndexAny(slug, "?#"); queryIndex != -1 {
+		slug = slug[:queryIndex]
+	}
 	
 	// Split by "-via-" to separate main route from transit
-	parts := strings.Split(url, "-via-")
-	if len(parts) < 2 {
-		return
+	parts := strings.Split(slug, "-via-")
+	if len(parts) != 2 {
+		return "", "", "", fmt.Errorf("cannot extract route from URL %q: expected <source>-to-<destination>-via-<transit>", url)
 	}
 	
 	mainRoute := parts[0]
-	viaPart := parts[1]
-	
-	// Extract transit station code (last part after final hyphen)
-	if strings.Contains(viaPart, "-") {
-		lastHyphen := strings.LastIndex(viaPart, "-")
-		if lastHyphen > 0 && lastHyphen < len(viaPart)-1 {
-			transitStation = viaPart[lastHyphen+1:]
-		}
-	}
 	
-	// Extract source and destination from main route
-	// Format: ...Source-SRCCODE-to-...Destination-DESTCODE-via-...
+	// Format: ...Source-SRCCODE-to-...Destination-DESTCODE
 	toIndex := strings.Index(mainRoute, "-to-")
 	if toIndex == -1 {
-		return
+		return "", "", "", fmt.Errorf("cannot extract route from URL %q: missing \"-to-\" separator", url)
 	}
 	
-	sourcePart := mainRoute[:toIndex]
-	destPart := mainRoute[toIndex+4:] // Skip "-to-"
-	
-	// Extract source station code (last part before "-to-")
-	if strings.Contains(sourcePart, "-") {
-		lastHyphen := strings.LastIndex(sourcePart, "-")
-		if lastHyphen > 0 && lastHyphen < len(sourcePart)-1 {
-			sourceStation = sourcePart[lastHyphen+1:]
+	// Station codes are the last hyphen-separated part of each segment
+	codes := []*string{&sourceStation, &destinationStation, &transitStation}
+	segments := []string{mainRoute[:toIndex], mainRoute[toIndex+4:], parts[1]}
+	for i, segment := range segments {
+		code := segment[strings.LastIndex(segment, "-")+1:]
+		if *codes[i], err = ValidateStationCode(code); err != nil {
+			return "", "", "", fmt.Errorf("cannot extract route from URL %q: %w", url, err)
 		}
 	}
 	
-	// Extract destination station code (last part before "-via-")
-	if strings.Contains(destPart, "-") {
-		lastHyphen := strings.LastIndex(destPart, "-")
-		if lastHyphen > 0 && lastHyphen < len(destPart)-1 {
-			destinationStation = destPart[lastHyphen+1:]
+	return sourceStation, destinationStation, transitStation, nil
+}
+
+// BuildViaSearchURL builds the etrain.info via-search URL for the given station codes
+func BuildViaSearchURL(sourceStation, destinationStation, transitStation string) (string, error) {
+	codes := []string{sourceStation, destinationStation, transitStation}
+	for i, code := range codes {
+		normalized, err := ValidateStationCode(code)
+		if err != nil {
+			return "", err
 		}
+		codes[i] = normalized
 	}
 	
-	return
-}
\ No newline at end of file
+	if codes[0] == codes[1] || codes[0] == codes[2] || codes[1] == codes[2] {
+		return "", fmt.Errorf("source, destination and transit stations must all differ (got %s, %s, %s)", codes[0], codes[1], codes[2])
+	}
+	
+	return fmt.Sprintf("%s/trains/%s-to-%s-via-%s", EtrainBaseURL, codes[0], codes[1], codes[2]), nil
+}
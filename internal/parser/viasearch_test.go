@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractRouteInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		source      string
+		destination string
+		transit     string
+		expectError bool
+	}{
+		{
+			name:        "Full station name slug",
+			input:       "https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED-via-Kalyan-Jn-KYN",
+			source:      "BL",
+			destination: "NED",
+			transit:     "KYN",
+		},
+		{
+			name:        "Code only slug",
+			input:       "https://etrain.info/trains/BL-to-NED-via-KYN",
+			source:      "BL",
+			destination: "NED",
+			transit:     "KYN",
+		},
+		{
+			name:        "Slug with query string",
+			input:       "https://etrain.info/trains/Valsad-BL-to-Pune-Jn-PUNE-via-Kalyan-Jn-KYN?x=1",
+			source:      "BL",
+			destination: "PUNE",
+			transit:     "KYN",
+		},
+		{
+			name:        "Missing via part",
+			input:       "https://etrain.info/trains/Valsad-BL-to-H-Sahib-Nanded-NED",
+			expectError: true,
+		},
+		{
+			name:        "Missing to separator",
+			input:       "https://etrain.info/trains/Valsad-BL-via-Kalyan-Jn-KYN",
+			expectError: true,
+		},
+		{
+			name:        "Invalid station code",
+			input:       "https://etrain.info/trains/Valsad-BL-to-Nanded-via-Kalyan-Jn-KYN",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, destination, transit, err := ExtractRouteInfo(tt.input)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("ExtractRouteInfo(%q) expected error but got none", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ExtractRouteInfo(%q) unexpected error: %v", tt.input, err)
+			}
+
+			if source != tt.source || destination != tt.destination || transit != tt.transit {
+				t.Errorf("ExtractRouteInfo(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.input, source, destination, transit, tt.source, tt.destination, tt.transit)
+			}
+		})
+	}
+}
+
+func TestBuildViaSearchURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		destination string
+		transit     string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:        "Valid codes",
+			source:      "BL",
+			destination: "NED",
+			transit:     "KYN",
+			expected:    "https://etrain.info/trains/BL-to-NED-via-KYN",
+		},
+		{
+			name:        "Lowercase codes are normalized",
+			source:      "bl",
+			destination: "ned",
+			transit:     "kyn",
+			expected:    "https://etrain.info/trains/BL-to-NED-via-KYN",
+		},
+		{
+			name:        "Invalid transit code",
+			source:      "BL",
+			destination: "NED",
+			transit:     "Kalyan Jn",
+			expectError: true,
+		},
+		{
+			name:        "Same source and transit",
+			source:      "BL",
+			destination: "NED",
+			transit:     "BL",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := BuildViaSearchURL(tt.source, tt.destination, tt.transit)
+
+			if tt.expectError && err == nil {
+				t.Errorf("BuildViaSearchURL(%q, %q, %q) expected error but got none", tt.source, tt.destination, tt.transit)
+			}
+
+			if !tt.expectError && err != nil {
+				t.Errorf("BuildViaSearchURL(%q, %q, %q) unexpected error: %v", tt.source, tt.destination, tt.transit, err)
+			}
+
+			if result != tt.expected {
+				t.Errorf("BuildViaSearchURL(%q, %q, %q) = %q, want %q", tt.source, tt.destination, tt.transit, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseTrainData(t *testing.T) {
+	page := `<table>
+<tr data-train='{"typ":"EXP","num":"11089","name":"BGKT {PUNE} EXPRESS","s":"BL","st":"01:08","d":"KYN","dt":"04:42","tt":"03:34","dy":"0001000","book":"","arp":3,"fare":{"SL":{"adult":210}}}'><td>11089</td></tr>
+<tr data-train="{&quot;num&quot;:12345,&quot;name&quot;:&quot;TEST &#39;SF&#39;&quot;,&quot;s&quot;:&quot;BL&quot;,&quot;st&quot;:&quot;10:00&quot;,&quot;d&quot;:&quot;KYN&quot;,&quot;dt&quot;:&quot;13:00&quot;,&quot;arp&quot;:&quot;1&quot;,&quot;cls&quot;:&quot;2A,3A SL&quot;,&quot;pantry&quot;:&quot;Y&quot;,&quot;dist&quot;:&quot;162 Kms&quot;}"><td>12345</td></tr>
+<tr data-train='{"num":"17617","s":"KYN","st":"06:27","d":"NED","dt":"18:00","classes":["1A","2A"],"pc":1,"distance":592}'><td>17617</td></tr>
+</table>`
+
+	trains, diagnostics, err := ParseTrainData(page)
+	if err != nil {
+		t.Fatalf("ParseTrainData() error: %v", err)
+	}
+	if diagnostics.String() != "parsed 3 of 3 rows" {
+		t.Errorf("diagnostics = %q, want all 3 rows parsed", diagnostics)
+	}
+	if len(trains) != 3 {
+		t.Fatalf("ParseTrainData() found %d trains, want 3", len(trains))
+	}
+
+	if trains[0].Name != "BGKT {PUNE} EXPRESS" || trains[0].ArrivalPlatform != 3 {
+		t.Errorf("train 1 = %+v, want name with braces and platform 3", trains[0])
+	}
+	if trains[1].Number != "12345" || trains[1].Name != "TEST 'SF'" || trains[1].ArrivalPlatform != 1 {
+		t.Errorf("train 2 = %+v, want decoded entities and numbers", trains[1])
+	}
+	if strings.Join(trains[1].Classes, " ") != "2A 3A SL" || !trains[1].Pantry || trains[1].Distance != 162 {
+		t.Errorf("train 2 extras = %v/%v/%d, want [2A 3A SL]/true/162", trains[1].Classes, trains[1].Pantry, trains[1].Distance)
+	}
+	if strings.Join(trains[2].Classes, " ") != "1A 2A" || !trains[2].Pantry || trains[2].Distance != 592 {
+		t.Errorf("train 3 extras = %v/%v/%d, want [1A 2A]/true/592", trains[2].Classes, trains[2].Pantry, trains[2].Distance)
+	}
+}
+
+func TestParseTrainDataMismatch(t *testing.T) {
+	page := `<div data-train='{"num":"11089","s":"BL","st":"01:08","d":"KYN","dt":"04:42"}'></div>
+<div data-train='{"num":"12345","s":"BL","st":"10:00"'></div>
+<div data-train='{"num":"17617","s":"KYN","st":"06:27","dt":"18:00"}'></div>`
+
+	trains, diagnostics, err := ParseTrainData(page)
+
+	var dataErr *TrainDataError
+	if !errors.As(err, &dataErr) {
+		t.Fatalf("ParseTrainData() error = %v, want *TrainDataError", err)
+	}
+	if diagnostics.RowsSeen != 3 || diagnostics.RowsParsed != 1 || len(trains) != 1 {
+		t.Errorf("seen %d, parsed %d, returned %d; want 3, 1, 1", diagnostics.RowsSeen, diagnostics.RowsParsed, len(trains))
+	}
+	if reasons := diagnostics.SkipReasons(); reasons[ReasonInvalidJSON] != 1 || reasons[ReasonMissingField] != 1 {
+		t.Errorf("skip reasons = %v, want 1 invalid JSON and 1 missing field", reasons)
+	}
+	if !strings.Contains(err.Error(), `missing field: "d"`) {
+		t.Errorf("error %q does not name the missing field", err)
+	}
+}
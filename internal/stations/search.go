@@ -0,0 +1,199 @@
+package stations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"trains/internal/parser"
+)
+
+// Match scores, higher is better
+const (
+	ScoreCode       = 100 // Query is the station code
+	ScoreName       = 90  // Query is the whole name
+	ScoreNamePrefix = 80  // Name starts with the query
+	ScoreWordPrefix = 70  // Every query word starts a word of the name
+	ScoreSubstring  = 60  // Name contains the query
+	ScoreTypo       = 40  // Every query word starts a name word or is within a small edit distance of one
+)
+
+// maxCandidates bounds the candidates listed for an ambiguous query
+const maxCandidates = 10
+
+// Match is a search result
+type Match struct {
+	Station
+	Score int
+}
+
+// AmbiguousError is returned by Resolve when a query matches several stations
+type AmbiguousError struct {
+	Query      string
+	Candidates []Match
+}
+
+// Error implements error, listing the candidates
+func (e *AmbiguousError) Error() string {
+	names := make([]string, 0, len(e.Candidates))
+	for _, candidate := range e.Candidates {
+		names = append(names, candidate.Station.String())
+	}
+	return fmt.Sprintf("station %q is ambiguous, use one of the codes: %s", e.Query, strings.Join(names, ", "))
+}
+
+// Search returns the stations matching query, best first
+func (d *Directory) Search(query string) []Match {
+	normalized := normalize(query)
+	if normalized == "" {
+		return nil
+	}
+	code := strings.ToUpper(strings.TrimSpace(query))
+
+	var matches []Match
+	for _, station := range d.stations {
+		if score := matchScore(station, code, normalized); score > 0 {
+			matches = append(matches, Match{Station: station, Score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if len(matches[i].Name) != len(matches[j].Name) {
+			return len(matches[i].Name) < len(matches[j].Name)
+		}
+		return matches[i].Code < matches[j].Code
+	})
+	return matches
+}
+
+// Resolve turns a station code or name into a station. A known code wins, then a whole name
+// matched by only one station. Other input shaped like a code is taken as that code even when the
+// directory does not know it: the directory only knows stations seen on parsed pages, so a code
+// must never be replaced by a station whose name it merely starts. The rest is matched against the
+// names; a name matched by only one station wins, and several matching stations give an
+// *AmbiguousError listing the candidates, best first.
+func (d *Directory) Resolve(query string) (Station, error) {
+	code, codeErr := parser.ValidateStationCode(query)
+	if codeErr == nil {
+		if station, found := d.Lookup(code); found {
+			return station, nil
+		}
+	}
+
+	matches := d.Search(query)
+	if len(matches) > 0 && matches[0].Score >= ScoreName && (len(matches) == 1 || matches[1].Score < ScoreName) {
+		return matches[0].Station, nil
+	}
+	if codeErr == nil {
+		return Station{Code: code}, nil
+	}
+
+	switch len(matches) {
+	case 0:
+		return Station{}, fmt.Errorf("unknown station %q: not a station code and no known station name matches (names are learned from topsearch and plan results, see 'trains stations search')", query)
+	case 1:
+		return matches[0].Station, nil
+	}
+
+	if len(matches) > maxCandidates {
+		matches = matches[:maxCandidates]
+	}
+	return Station{}, &AmbiguousError{Query: query, Candidates: matches}
+}
+
+// matchScore rates how well a station matches a query given as an upper-case code and in normalized form
+func matchScore(station Station, code, query string) int {
+	if station.Code == code {
+		return ScoreCode
+	}
+
+	name := normalize(station.Name)
+	switch {
+	case name == query:
+		return ScoreName
+	case strings.HasPrefix(name, query):
+		return ScoreNamePrefix
+	}
+
+	nameWords := strings.Fields(name)
+	queryWords := strings.Fields(query)
+	switch {
+	case allWordsMatch(queryWords, nameWords, strings.HasPrefix):
+		return ScoreWordPrefix
+	case strings.Contains(name, query):
+		return ScoreSubstring
+	case allWordsMatch(queryWords, nameWords, prefixOrCloseTo):
+		return ScoreTypo
+	}
+	return 0
+}
+
+// allWordsMatch reports whether every query word matches some name word
+func allWordsMatch(queryWords, nameWords []string, match func(word, query string) bool) bool {
+	for _, queryWord := range queryWords {
+		found := false
+		for _, nameWord := range nameWords {
+			if match(nameWord, queryWord) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(queryWords) > 0
+}
+
+// prefixOrCloseTo reports whether query starts word or is a likely misspelling of it
+func prefixOrCloseTo(word, query string) bool {
+	return strings.HasPrefix(word, query) || closeTo(word, query)
+}
+
+// closeTo reports whether query is a likely misspelling of word: one edit for short words, two for longer ones
+func closeTo(word, query string) bool {
+	if len(query) < 4 {
+		return false
+	}
+	allowed := 1
+	if len(query) >= 7 {
+		allowed = 2
+	}
+	// Also accept a misspelled prefix, e.g. "nandd" for "nanded road"
+	if len(word) > len(query)+allowed {
+		word = word[:len(query)+allowed]
+	}
+	return editDistance(word, query) <= allowed
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// normalize lower-cases s and turns punctuation into single spaces, e.g. "H.Sahib  Nanded" -> "h sahib nanded"
+func normalize(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,183 @@
+// Package stations keeps a local directory of station names and codes collected from parsed
+// pages, so that commands can accept station names as well as codes.
+package stations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"trains/internal/types"
+)
+
+const (
+	// dirName is the subdirectory of the cache directory holding the directory file,
+	// kept apart from cache entries so cache commands never see it
+	dirName = "stations"
+
+	fileName = "stations.json"
+
+	// fileVersion is bumped when the file layout changes incompatibly
+	fileVersion = 1
+)
+
+// Station is one entry of the directory
+type Station struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// String returns the station as "KALYAN JN (KYN)"
+func (s Station) String() string {
+	if s.Name == "" {
+		return s.Code
+	}
+	return fmt.Sprintf("%s (%s)", s.Name, s.Code)
+}
+
+// Directory maps station codes to names
+type Directory struct {
+	stations map[string]Station
+	changed  bool
+}
+
+// file is the on-disk form of a Directory
+type file struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Stations  []Station `json:"stations"`
+}
+
+// Path returns where the directory is stored for the given cache directory
+func Path(cacheDir string) string {
+	return filepath.Join(cacheDir, dirName, fileName)
+}
+
+// New returns an empty directory
+func New() *Directory {
+	return &Directory{stations: make(map[string]Station)}
+}
+
+// Load reads the directory at path; a missing file is an empty directory
+func Load(path string) (*Directory, error) {
+	dir := New()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return dir, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read station directory %s: %w", path, err)
+	}
+
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse station directory %s: %w", path, err)
+	}
+	if stored.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported station directory version %d in %s (want %d)", stored.Version, path, fileVersion)
+	}
+
+	for _, station := range stored.Stations {
+		dir.stations[station.Code] = station
+	}
+	return dir, nil
+}
+
+// Save writes the directory to path if it changed since it was loaded
+func (d *Directory) Save(path string) error {
+	if !d.changed {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(file{Version: fileVersion, UpdatedAt: time.Now(), Stations: d.All()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode station directory: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create station directory %s: %w", filepath.Dir(path), err)
+	}
+
+	// Write to a temporary file first so concurrent runs never read a partial directory
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary station directory file: %w", err)
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write station directory %s: %w", path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to close station directory %s: %w", path, err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move station directory into place %s: %w", path, err)
+	}
+
+	d.changed = false
+	return nil
+}
+
+// Add records a station, returning whether the directory changed. Later names replace earlier
+// ones, so the directory follows renames on the site.
+func (d *Directory) Add(code, name string) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	name = strings.Join(strings.Fields(name), " ")
+	if code == "" || name == "" {
+		return false
+	}
+
+	if existing, found := d.stations[code]; found && existing.Name == name {
+		return false
+	}
+	d.stations[code] = Station{Code: code, Name: name}
+	d.changed = true
+	return true
+}
+
+// AddRoutes records the source, transit and destination stations of transit routes,
+// returning how many stations were added or renamed
+func (d *Directory) AddRoutes(routes []types.TransitRoute) int {
+	added := 0
+	for _, route := range routes {
+		for _, station := range [][2]string{
+			{route.SourceStationCode, route.SourceStation},
+			{route.TransitStationCode, route.TransitStation},
+			{route.DestStationCode, route.DestStation},
+		} {
+			if d.Add(station[0], station[1]) {
+				added++
+			}
+		}
+	}
+	return added
+}
+
+// Lookup returns the station with the given code
+func (d *Directory) Lookup(code string) (Station, bool) {
+	station, found := d.stations[strings.ToUpper(strings.TrimSpace(code))]
+	return station, found
+}
+
+// All returns every station, sorted by code
+func (d *Directory) All() []Station {
+	all := make([]Station, 0, len(d.stations))
+	for _, station := range d.stations {
+		all = append(all, station)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Code < all[j].Code })
+	return all
+}
+
+// Len returns the number of stations
+func (d *Directory) Len() int {
+	return len(d.stations)
+}
@@ -0,0 +1,125 @@
+package stations
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"trains/internal/types"
+)
+
+func testDirectory() *Directory {
+	dir := New()
+	dir.AddRoutes([]types.TransitRoute{
+		{SourceStation: "VALSAD", SourceStationCode: "BL", TransitStation: "KALYAN JN", TransitStationCode: "KYN", DestStation: "H SAHIB NANDED", DestStationCode: "NED"},
+		{SourceStation: "VALSAD", SourceStationCode: "BL", TransitStation: "PUNE JN", TransitStationCode: "PUNE", DestStation: "H.Sahib Nanded", DestStationCode: "NED"},
+	})
+	dir.Add("NDR", "NANDED ROAD")
+	dir.Add("ST", "SURAT")
+	return dir
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := Path(t.TempDir())
+	dir := testDirectory()
+	if err := dir.Save(path); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if loaded.Len() != 6 {
+		t.Errorf("loaded %d stations, want 6", loaded.Len())
+	}
+	// The later spelling of a name wins
+	if station, _ := loaded.Lookup("ned"); station.Name != "H.Sahib Nanded" {
+		t.Errorf("Lookup(ned) = %v, want the latest name", station)
+	}
+
+	missing, err := Load(filepath.Join(t.TempDir(), "none.json"))
+	if err != nil || missing.Len() != 0 {
+		t.Errorf("Load(missing) = %d stations, %v; want an empty directory", missing.Len(), err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	dir := testDirectory()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"kyn", []string{"KYN"}},
+		{"kalyan", []string{"KYN"}},
+		{"nanded", []string{"NDR", "NED"}},
+		{"sahib nanded", []string{"NED"}},
+		{"nandd", []string{"NDR", "NED"}},
+		{"kalyn jn", []string{"KYN"}},
+		{"jn", []string{"PUNE", "KYN"}}, // Shorter names first
+		{"xyz", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			matches := dir.Search(tt.query)
+			var codes []string
+			for _, match := range matches {
+				codes = append(codes, match.Code)
+			}
+			if len(codes) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, codes, tt.want)
+			}
+			for i := range codes {
+				if codes[i] != tt.want[i] {
+					t.Errorf("Search(%q) = %v, want %v", tt.query, codes, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := testDirectory()
+	dir.Add("NEDM", "NEDUMANGAD")
+	dir.Add("AMNG", "AMINGAON")
+
+	tests := []struct {
+		query     string
+		want      string
+		ambiguous bool
+		wantErr   bool
+	}{
+		{query: "KYN", want: "KYN"},
+		{query: "kalyan jn", want: "KYN"},
+		{query: "Kalyan", want: "KYN"},
+		{query: "DD", want: "DD"},     // Unknown but shaped like a code
+		{query: "NED", want: "NED"},   // A code, although it starts the name NEDUMANGAD
+		{query: "ami", want: "AMI"},   // Unknown code starting the only name AMINGAON
+		{query: "surat", want: "ST"},  // Whole name before an unknown code
+		{query: "sura", want: "SURA"}, // Unknown code starting the name SURAT
+		{query: "surat city", wantErr: true},
+		{query: "nanded", ambiguous: true},
+		{query: "daund junction", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			station, err := dir.Resolve(tt.query)
+
+			var ambiguous *AmbiguousError
+			switch {
+			case tt.ambiguous:
+				if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+					t.Errorf("Resolve(%q) = %v, %v; want 2 candidates", tt.query, station, err)
+				}
+			case tt.wantErr:
+				if err == nil || errors.As(err, &ambiguous) {
+					t.Errorf("Resolve(%q) = %v, %v; want unknown station error", tt.query, station, err)
+				}
+			case err != nil || station.Code != tt.want:
+				t.Errorf("Resolve(%q) = %v, %v; want %s", tt.query, station, err, tt.want)
+			}
+		})
+	}
+}
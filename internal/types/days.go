@@ -0,0 +1,71 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// DayMask is a set of weekdays, bit 0 = Sunday ... bit 6 = Saturday
+type DayMask uint8
+
+// AllDays is the mask of a train running daily
+const AllDays DayMask = 1<<7 - 1
+
+// dayAbbreviations are the short weekday names indexed by time.Weekday
+var dayAbbreviations = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// ParseDayMask converts an etrain.info running days string like "1010101" to a DayMask
+func ParseDayMask(dayStr string) DayMask {
+	var mask DayMask
+	for i, char := range dayStr {
+		if i < 7 && char == '1' {
+			mask |= 1 << i
+		}
+	}
+	return mask
+}
+
+// DayMaskOf returns the mask containing only day
+func DayMaskOf(day time.Weekday) DayMask {
+	return 1 << (int(day) % 7)
+}
+
+// Has reports whether day is in the mask
+func (m DayMask) Has(day time.Weekday) bool {
+	return m&DayMaskOf(day) != 0
+}
+
+// Rotate moves every day later by offset days (negative offsets move days earlier)
+func (m DayMask) Rotate(offset int) DayMask {
+	offset = (offset%7 + 7) % 7
+	m &= AllDays
+	return (m<<offset | m>>(7-offset)) & AllDays
+}
+
+// Bits returns the mask in etrain.info running days format, e.g. "1010101"
+func (m DayMask) Bits() string {
+	bits := make([]byte, 7)
+	for i := range bits {
+		bits[i] = '0'
+		if m&(1<<i) != 0 {
+			bits[i] = '1'
+		}
+	}
+	return string(bits)
+}
+
+// Days returns the short names of the days in the mask, Sunday first
+func (m DayMask) Days() []string {
+	days := make([]string, 0, 7)
+	for i, name := range dayAbbreviations {
+		if m&(1<<i) != 0 {
+			days = append(days, name)
+		}
+	}
+	return days
+}
+
+// String returns the days as a comma-separated list, e.g. "Sun,Wed"
+func (m DayMask) String() string {
+	return strings.Join(m.Days(), ",")
+}
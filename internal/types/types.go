@@ -7,25 +7,82 @@ import (
 
 // TrainData represents train information from etrain.info
 type TrainData struct {
-	Type               string `json:"typ"`
-	Number             string `json:"num"`
-	Name               string `json:"name"`
-	SourceStationCode  string `json:"s"`    // Source station code
-	SourceTime         string `json:"st"`   // Source time
-	DestStationCode    string `json:"d"`    // Destination station code  
-	DestTime           string `json:"dt"`   // Destination time
-	TravelTime         string `json:"tt"`   // Travel time
-	RunningDays        string `json:"dy"`   // Running days (1=Sun, 2=Mon, etc.)
-	BookingInfo        string `json:"book"`
-	ArrivalPlatform    int    `json:"arp"`
+	Type              string `json:"typ"`
+	Number            string `json:"num"`
+	Name              string `json:"name"`
+	SourceStationCode string `json:"s"`  // Source station code
+	SourceTime        string `json:"st"` // Source time
+	DestStationCode   string `json:"d"`  // Destination station code
+	DestTime          string `json:"dt"` // Destination time
+	TravelTime        string `json:"tt"` // Travel time
+	RunningDays       string `json:"dy"` // Running days (1=Sun, 2=Mon, etc.)
+	BookingInfo       string `json:"book"`
+	ArrivalPlatform   int    `json:"arp"`
+
+	// Only present on some pages; zero when missing
+	Classes  []string `json:"cls,omitempty"`    // Travel classes, e.g. 2A, 3A, SL
+	Pantry   bool     `json:"pantry,omitempty"` // Pantry car available
+	Distance int      `json:"dist,omitempty"`   // Distance in km
+}
+
+// Rejection explains why two trains do not form a valid connection
+type Rejection int
+
+const (
+	NotRejected             Rejection = iota // Valid connection
+	RejectedNoCommonDays                     // Trains never run on matching days
+	RejectedLayoverTooShort                  // Layover below the minimum
+	RejectedLayoverTooLong                   // Layover above the maximum
+	RejectedJourneyTooLong                   // Total journey time not under the maximum
+)
+
+// String returns a threshold-independent description of the rejection
+func (r Rejection) String() string {
+	switch r {
+	case NotRejected:
+		return "Valid connection"
+	case RejectedNoCommonDays:
+		return "No common running days"
+	case RejectedLayoverTooShort:
+		return "Insufficient layover time"
+	case RejectedLayoverTooLong:
+		return "Layover too long"
+	case RejectedJourneyTooLong:
+		return "Journey too long"
+	}
+	return fmt.Sprintf("Rejection(%d)", int(r))
 }
 
 // RouteConnection represents a connection between two trains via intermediate station
 type RouteConnection struct {
-	Train1     TrainData
-	Train2     TrainData
-	TotalTime  string
-	Connection string
+	Train1         TrainData
+	Train2         TrainData
+	TotalTime      string
+	Connection     string
+	LayoverMinutes int // Wait at the transit station in minutes
+	TotalMinutes   int // Total journey time in minutes
+	Rejection      Rejection
+
+	// Day offsets relative to the day Train1 leaves the source station (0 = same day)
+	TransitArrivalDay   int // Day Train1 reaches the transit station
+	TransitDepartureDay int // Day Train2 leaves the transit station
+
+	// Days on which the whole connection works, as departure days from the source station
+	RunningDays DayMask
+
+	// Absolute departure and arrival times on the travel date, only set when searching by date
+	Schedule *Schedule
+
+	// Pareto criteria this connection is best on, only set when filtering with --pareto
+	BestFor []string
+}
+
+// Schedule holds the departure and arrival times of both trains of a connection on a travel date
+type Schedule struct {
+	Train1Departure time.Time
+	Train1Arrival   time.Time // At the transit station
+	Train2Departure time.Time // From the transit station
+	Train2Arrival   time.Time
 }
 
 // TransitRoute represents a transit route between stations
@@ -47,6 +104,9 @@ type CacheEntry struct {
 	URL       string    `json:"url"`
 	Content   string    `json:"content"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// Hex SHA-256 of Content, checked on load; empty for entries written by older versions
+	ContentHash string `json:"content_sha256,omitempty"`
 }
 
 // String returns a string representation of TrainData
@@ -61,13 +121,13 @@ func (r RouteConnection) String() string {
 
 // String returns a string representation of TransitRoute
 func (t TransitRoute) String() string {
-	return fmt.Sprintf("%s (%s) → %s (%s) → %s (%s) | Distance: %s | Trains: %d+%d=%d", 
-		t.SourceStation, t.SourceStationCode, t.TransitStation, t.TransitStationCode, 
-		t.DestStation, t.DestStationCode, t.Distance, t.SourceTrainCount, 
+	return fmt.Sprintf("%s (%s) → %s (%s) → %s (%s) | Distance: %s | Trains: %d+%d=%d",
+		t.SourceStation, t.SourceStationCode, t.TransitStation, t.TransitStationCode,
+		t.DestStation, t.DestStationCode, t.Distance, t.SourceTrainCount,
 		t.TransitTrainCount, t.SourceTrainCount+t.TransitTrainCount)
 }
 
 // String returns a string representation of CacheEntry
 func (c CacheEntry) String() string {
 	return fmt.Sprintf("Cache[%s] from %v", c.URL, c.Timestamp.Format("2006-01-02 15:04:05"))
-}
\ No newline at end of file
+}